@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// Handler serves the GET/POST/PUT/DELETE recipe API on top of any store, so that every route
+// behaves the same no matter which database it uses.
+type Handler struct {
+	Store store.RecipeStore
+	// Fetch parses a recipe from its (canonicalized) source URL. Defaults to RecipeFromSource.
+	// The snapshot in the result is saved along with the recipe.
+	Fetch func(source string) (*recipe.Result, error)
+}
+
+// NewHandler returns a handler for the store that parses recipes from their websites.
+func NewHandler(s store.RecipeStore) *Handler {
+	return &Handler{Store: s, Fetch: RecipeFromSource}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+	switch r.Method {
+	case "GET":
+		err = h.get(w, r)
+	case "POST":
+		err = h.post(w, r)
+	case "PUT":
+		err = h.put(w, r)
+	case "DELETE":
+		err = h.delete(w, r)
+	default:
+		http.Error(w, "Error: method not allowed", http.StatusMethodNotAllowed)
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) fetch(source string) (*recipe.Result, error) {
+	if h.Fetch == nil {
+		return RecipeFromSource(source)
+	}
+	return h.Fetch(source)
+}
+
+// Retrieves an entry from the database or parses it directly from the website.
+// If the item isn't found in the database, then it will be parsed.
+// To check if the item is in the database, I check the URL fields.
+// I could also simply parse the site directly and determine which version to return,
+// but I don't want to make more networks calls to the website than necessary.
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	source, ok := nameParam(w, query)
+	if !ok {
+		return nil
+	}
+
+	if _, src := query["src"]; src {
+		res, err := h.fetch(source)
+		if err != nil {
+			return err
+		}
+		return WriteQueriedRecipe(w, query, res.Recipe)
+	}
+
+	rcp, err := h.Store.GetByURL(r.Context(), source)
+	if err == store.ErrNotFound {
+		var res *recipe.Result
+		if res, err = h.fetch(source); err == nil {
+			rcp = res.Recipe
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return WriteQueriedRecipe(w, query, rcp)
+}
+
+// Adds a new record to the database, failing if that item already exists.
+// Use PUT to update saved recipes.
+func (h *Handler) post(w http.ResponseWriter, r *http.Request) error {
+	source, ok := nameParam(w, r.URL.Query())
+	if !ok {
+		return nil
+	}
+
+	_, err := h.Store.GetByURL(r.Context(), source)
+	if err == nil {
+		http.Error(w, "recipe already exists", http.StatusBadRequest)
+		return nil
+	}
+	if err != store.ErrNotFound {
+		return err
+	}
+
+	res, err := h.fetch(source)
+	if err != nil {
+		return err
+	}
+
+	// I can do a more thorough check now that the recipe has been parsed.
+	_, err = h.Store.GetByID(r.Context(), res.Recipe.ID)
+	if err == nil {
+		http.Error(w, "recipe already exists", http.StatusBadRequest)
+		return nil
+	}
+	if err != store.ErrNotFound {
+		return err
+	}
+	if err = store.Save(r.Context(), h.Store, res.Recipe, res.Snapshot); err != nil {
+		return err
+	}
+	return WriteRecipe(w, res.Recipe, http.StatusOK)
+}
+
+// Updates an existing record in the database. WILL NOT create a new record, use POST.
+func (h *Handler) put(w http.ResponseWriter, r *http.Request) error {
+	id, ok := idParam(w, r.URL.Query())
+	if !ok {
+		return nil
+	}
+
+	rcp, err := h.Store.GetByID(r.Context(), id)
+	if err == store.ErrNotFound {
+		http.Error(w, "id does not exist", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := h.fetch(rcp.URL)
+	if err != nil {
+		return err
+	}
+	// The page should give the same ID, but the stored recipe is the one being updated.
+	res.Recipe.ID = id
+
+	if err = store.Save(r.Context(), h.Store, res.Recipe, res.Snapshot); err != nil {
+		return err
+	}
+	return WriteRecipe(w, res.Recipe, http.StatusOK)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) error {
+	id, ok := idParam(w, r.URL.Query())
+	if !ok {
+		return nil
+	}
+
+	err := h.Store.Delete(r.Context(), id)
+	if err == store.ErrNotFound {
+		http.Error(w, "id does not exist", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// nameParam returns the canonicalized "name" parameter, or writes a 400 Bad Request if it is missing.
+func nameParam(w http.ResponseWriter, query url.Values) (string, bool) {
+	if names, ok := query["name"]; ok && len(names) > 0 && names[0] != "" {
+		return Canonicalize(names[0]), true
+	}
+	http.Error(w, "Error: no name given", http.StatusBadRequest)
+	return "", false
+}
+
+// idParam returns the "id" parameter, or writes a 400 Bad Request if it is missing.
+func idParam(w http.ResponseWriter, query url.Values) (string, bool) {
+	if ids, ok := query["id"]; ok && len(ids) > 0 && ids[0] != "" {
+		return ids[0], true
+	}
+	http.Error(w, "Error: no id given", http.StatusBadRequest)
+	return "", false
+}
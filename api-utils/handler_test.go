@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+const (
+	potatoesURL  = "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/"
+	potatoesPage = "<html>Slow Cooker Mashed Potatoes</html>"
+)
+
+func testHandler(t *testing.T) (*Handler, *int) {
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetches := 0
+	fetch := func(source string) (*recipe.Result, error) {
+		fetches++
+		if source != potatoesURL {
+			return nil, errors.New("no recipe at " + source)
+		}
+		rcp := &models.Recipe{
+			ID:   "30990",
+			Name: "Slow Cooker Mashed Potatoes",
+			URL:  potatoesURL,
+			Ingredients: models.IngredientGroups{{Ingredients: []models.Ingredient{
+				{Amount: "3", Unit: "lbs.", Name: "russet potatoes"},
+			}}},
+			Servings: 6,
+		}
+		snap := models.NewSnapshot(source, time.Now().UTC(), http.StatusOK, nil, []byte(potatoesPage))
+		return &recipe.Result{Recipe: rcp, Strategy: recipe.WPRM, Snapshot: snap}, nil
+	}
+	return &Handler{Store: s, Fetch: fetch}, &fetches
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	h, fetches := testHandler(t)
+
+	tests := []struct {
+		method, target string
+		status         int
+		fetches        int
+	}{
+		{"GET", "/api/recipe", http.StatusBadRequest, 0},
+		{"GET", "/api/recipe?name=slow-cooker-mashed-potatoes", http.StatusOK, 1},
+		{"PUT", "/api/recipe?id=30990", http.StatusNotFound, 1},
+		{"POST", "/api/recipe?name=slow-cooker-mashed-potatoes", http.StatusOK, 2},
+		{"POST", "/api/recipe?name=" + potatoesURL, http.StatusBadRequest, 2},
+		// stored recipes aren't fetched again, unless "src" is given
+		{"GET", "/api/recipe?name=slow-cooker-mashed-potatoes", http.StatusOK, 2},
+		{"GET", "/api/recipe?name=slow-cooker-mashed-potatoes&src", http.StatusOK, 3},
+		{"GET", "/api/recipe?name=slow-cooker-mashed-potatoes&scale=abc", http.StatusBadRequest, 3},
+		{"PUT", "/api/recipe?id=30990", http.StatusOK, 4},
+		{"DELETE", "/api/recipe", http.StatusBadRequest, 4},
+		{"DELETE", "/api/recipe?id=30990", http.StatusOK, 4},
+		{"DELETE", "/api/recipe?id=30990", http.StatusNotFound, 4},
+		{"PATCH", "/api/recipe?id=30990", http.StatusMethodNotAllowed, 4},
+	}
+
+	for _, test := range tests {
+		w := serve(h, test.method, test.target)
+		if w.Code != test.status {
+			t.Errorf("%s %s = %d, want %d: %s", test.method, test.target, w.Code, test.status, w.Body)
+		}
+		if *fetches != test.fetches {
+			t.Errorf("%s %s: %d fetches so far, want %d", test.method, test.target, *fetches, test.fetches)
+		}
+	}
+}
+
+// The page that a recipe was parsed from is saved with it, so that it can be parsed again later.
+func TestHandlerSnapshot(t *testing.T) {
+	h, _ := testHandler(t)
+	snapshots := h.Store.(store.SnapshotStore)
+
+	for _, method := range []string{"POST", "PUT"} {
+		target := "/api/recipe?name=slow-cooker-mashed-potatoes"
+		if method == "PUT" {
+			target = "/api/recipe?id=30990"
+		}
+		if w := serve(h, method, target); w.Code != http.StatusOK {
+			t.Fatalf("%s status = %d: %s", method, w.Code, w.Body)
+		}
+		rcp, err := h.Store.GetByID(context.Background(), "30990")
+		if err != nil {
+			t.Fatal(err)
+		}
+		snap, err := snapshots.GetSnapshot(context.Background(), rcp)
+		if err != nil {
+			t.Fatalf("GetSnapshot after %s: %v", method, err)
+		}
+		if snap.URL != potatoesURL || string(snap.Body) != potatoesPage {
+			t.Errorf("snapshot after %s = %s %q, want the fetched page", method, snap.URL, snap.Body)
+		}
+	}
+}
+
+func TestHandlerOptions(t *testing.T) {
+	h, _ := testHandler(t)
+
+	w := serve(h, "GET", "/api/recipe?name=slow-cooker-mashed-potatoes&servings=12&units=metric")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	rcp := &models.Recipe{}
+	if err := json.Unmarshal(w.Body.Bytes(), rcp); err != nil {
+		t.Fatal(err)
+	}
+	i := rcp.AllIngredients()[0]
+	if rcp.Servings != 12 || i.Amount != "2.7" || i.Unit != "kg" {
+		t.Errorf("got %v servings of %s %s, want 12 servings of 2.7 kg", rcp.Servings, i.Amount, i.Unit)
+	}
+
+	w = serve(h, "GET", "/api/recipe?name=slow-cooker-mashed-potatoes&servings=12&scale=2")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status with both scale and servings = %d, want 400", w.Code)
+	}
+}
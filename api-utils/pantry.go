@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ejacobg/recipe-parser/pantry"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// PantryHandler serves GET requests for the stored recipes that can be made with the ingredients
+// on hand, best covered first (see pantry.Pantry.Rank). The parameters are:
+//   - have: the ingredients on hand, comma separated or repeated
+//   - min: only return recipes with at least this fraction of their ingredients on hand, 0.5 by default
+//   - limit: the most recipes to return, DefaultPageSize by default and at most MaxPageSize
+type PantryHandler struct {
+	Store store.RecipeStore
+}
+
+func (h *PantryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Error: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	items := listParam(query, "have")
+	if len(items) == 0 {
+		http.Error(w, "Error: no ingredients given", http.StatusBadRequest)
+		return
+	}
+	min, limit, err := pantryParams(query)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recipes, err := h.Store.List(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	matches := pantry.New(items).Rank(recipes, min)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	data, err := json.Marshal(matches)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func pantryParams(query url.Values) (min float64, limit int, err error) {
+	min, limit = 0.5, DefaultPageSize
+	if s := query.Get("min"); s != "" {
+		if min, err = strconv.ParseFloat(s, 64); err != nil || min < 0 || min > 1 {
+			return 0, 0, errors.New("min must be a number from 0 to 1")
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > MaxPageSize {
+			return 0, 0, errors.New("limit must be between 1 and " + strconv.Itoa(MaxPageSize))
+		}
+	}
+	return min, limit, nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ejacobg/recipe-parser/pantry"
+)
+
+func TestPantryHandler(t *testing.T) {
+	h, _ := testHandler(t)
+	if w := serve(h, "POST", "/api/recipe?name=slow-cooker-mashed-potatoes"); w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d: %s", w.Code, w.Body)
+	}
+	ph := &PantryHandler{Store: h.Store}
+
+	w := serve(ph, "GET", "/api/pantry?have=potatoes,garlic")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var matches []pantry.Match
+	if err := json.Unmarshal(w.Body.Bytes(), &matches); err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Coverage != 1 || len(matches[0].Missing) != 0 {
+		t.Errorf("got %+v, want the recipe fully covered", matches)
+	}
+
+	if w = serve(ph, "GET", "/api/pantry?have=garlic"); w.Body.String() != "[]" {
+		t.Errorf("got %s, want no recipes below the minimum coverage", w.Body)
+	}
+	for _, target := range []string{"/api/pantry", "/api/pantry?have=garlic&min=2", "/api/pantry?have=garlic&limit=0"} {
+		if w = serve(ph, "GET", target); w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", target, w.Code)
+		}
+	}
+}
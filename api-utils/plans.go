@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/mealplan"
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/shopping"
+	"github.com/ejacobg/recipe-parser/store"
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+// PlanStore is a store that holds both recipes and meal plans.
+type PlanStore interface {
+	store.RecipeStore
+	store.PlanStore
+}
+
+// PlanHandler serves the meal plan API:
+//   - GET lists every plan, or returns the plan with the given "id". With "view", the plan's meals
+//     (optionally only those between the dates "from" and "to") are turned into a "shopping" list
+//     (see ShoppingHandler for "format" and "units"), their total "cost", or an "ics" calendar.
+//   - POST creates the plan given as JSON in the body, failing if it already exists.
+//   - PUT replaces the plan with the given "id" with the one in the body.
+//   - DELETE deletes the plan with the given "id".
+type PlanHandler struct {
+	Store PlanStore
+}
+
+// maxPlanSize limits the size of a plan sent in a request body.
+const maxPlanSize = 1 << 20
+
+func (h *PlanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+	switch r.Method {
+	case "GET":
+		err = h.get(w, r)
+	case "POST":
+		err = h.post(w, r)
+	case "PUT":
+		err = h.put(w, r)
+	case "DELETE":
+		err = h.delete(w, r)
+	default:
+		http.Error(w, "Error: method not allowed", http.StatusMethodNotAllowed)
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}, status int) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+	return nil
+}
+
+// getPlan returns the plan named by the "id" parameter, or writes an error and returns nil.
+func (h *PlanHandler) getPlan(w http.ResponseWriter, r *http.Request) (*models.Plan, error) {
+	id, ok := idParam(w, r.URL.Query())
+	if !ok {
+		return nil, nil
+	}
+	plan, err := h.Store.GetPlan(r.Context(), id)
+	if err == store.ErrPlanNotFound {
+		http.Error(w, "plan does not exist", http.StatusNotFound)
+		return nil, nil
+	}
+	return plan, err
+}
+
+func (h *PlanHandler) get(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	if !query.Has("id") {
+		plans, err := h.Store.ListPlans(r.Context())
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, plans, http.StatusOK)
+	}
+	plan, err := h.getPlan(w, r)
+	if plan == nil || err != nil {
+		return err
+	}
+	if !query.Has("view") {
+		return writeJSON(w, plan, http.StatusOK)
+	}
+
+	from, to, err := dateRange(query)
+	if err != nil {
+		http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	planned, err := mealplan.Load(r.Context(), h.Store, plan.Between(from, to))
+	if err != nil {
+		// a recipe was deleted or can't be scaled
+		http.Error(w, "Error: "+err.Error(), http.StatusConflict)
+		return nil
+	}
+
+	switch query.Get("view") {
+	case "shopping":
+		format, system := shopping.JSON, units.None
+		if query.Has("format") {
+			if format, err = shopping.ParseFormat(query.Get("format")); err != nil {
+				http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+				return nil
+			}
+		}
+		if query.Has("units") {
+			if system, err = units.ParseSystem(query.Get("units")); err != nil {
+				http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+				return nil
+			}
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		return mealplan.ShoppingList(planned, system).Write(w, format)
+	case "cost":
+		return writeJSON(w, mealplan.TotalCost(planned), http.StatusOK)
+	case "ics":
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+plan.ID+`.ics"`)
+		return mealplan.WriteICS(w, plan, planned, time.Now())
+	}
+	http.Error(w, `Error: view must be "shopping", "cost" or "ics"`, http.StatusBadRequest)
+	return nil
+}
+
+// dateRange reads the optional "from" and "to" dates.
+func dateRange(query url.Values) (from, to string, err error) {
+	from, to = query.Get("from"), query.Get("to")
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse(models.DateFormat, date); date != "" && err != nil {
+			return "", "", errors.New(`invalid date "` + date + `", use YYYY-MM-DD`)
+		}
+	}
+	return from, to, nil
+}
+
+// readPlan decodes the plan in the request body, or writes a 400 Bad Request and returns nil.
+func readPlan(w http.ResponseWriter, r *http.Request) *models.Plan {
+	plan := &models.Plan{}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPlanSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(plan); err != nil {
+		http.Error(w, "Error: invalid plan: "+err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	if plan.Meals == nil {
+		plan.Meals = []models.Meal{}
+	}
+	if err := plan.Validate(); err != nil {
+		http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	plan.Sort()
+	return plan
+}
+
+func (h *PlanHandler) post(w http.ResponseWriter, r *http.Request) error {
+	plan := readPlan(w, r)
+	if plan == nil {
+		return nil
+	}
+	_, err := h.Store.GetPlan(r.Context(), plan.ID)
+	if err == nil {
+		http.Error(w, "plan already exists", http.StatusBadRequest)
+		return nil
+	}
+	if err != store.ErrPlanNotFound {
+		return err
+	}
+	if err = h.Store.SavePlan(r.Context(), plan); err != nil {
+		return err
+	}
+	return writeJSON(w, plan, http.StatusCreated)
+}
+
+// Replaces an existing plan. WILL NOT create a new plan, use POST.
+func (h *PlanHandler) put(w http.ResponseWriter, r *http.Request) error {
+	old, err := h.getPlan(w, r)
+	if old == nil || err != nil {
+		return err
+	}
+	plan := readPlan(w, r)
+	if plan == nil {
+		return nil
+	}
+	if plan.ID != old.ID {
+		http.Error(w, "Error: the plan's id can't be changed", http.StatusBadRequest)
+		return nil
+	}
+	if err = h.Store.SavePlan(r.Context(), plan); err != nil {
+		return err
+	}
+	return writeJSON(w, plan, http.StatusOK)
+}
+
+func (h *PlanHandler) delete(w http.ResponseWriter, r *http.Request) error {
+	id, ok := idParam(w, r.URL.Query())
+	if !ok {
+		return nil
+	}
+	err := h.Store.DeletePlan(r.Context(), id)
+	if err == store.ErrPlanNotFound {
+		http.Error(w, "plan does not exist", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ejacobg/recipe-parser/mealplan"
+	"github.com/ejacobg/recipe-parser/models"
+)
+
+func sendPlan(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestPlanHandler(t *testing.T) {
+	h, _ := testHandler(t)
+	if w := serve(h, "POST", "/api/recipe?name=slow-cooker-mashed-potatoes"); w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d: %s", w.Code, w.Body)
+	}
+	ph := &PlanHandler{Store: h.Store.(PlanStore)}
+
+	plan := `{"id": "week", "name": "This week", "meals": [
+		{"date": "2022-10-25", "slot": "dinner", "recipeId": "30990", "servings": 12},
+		{"date": "2022-10-24", "slot": "dinner", "recipeId": "30990"}
+	]}`
+	if w := sendPlan(ph, "POST", "/api/plans", plan); w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d: %s", w.Code, w.Body)
+	}
+
+	w := serve(ph, "GET", "/api/plans?id=week")
+	var got models.Plan
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "This week" || len(got.Meals) != 2 || got.Meals[0].Date != "2022-10-24" {
+		t.Errorf("GET = %+v, want the sorted plan", got)
+	}
+
+	w = serve(ph, "GET", "/api/plans?id=week&view=shopping&format=text&from=2022-10-25")
+	if !strings.Contains(w.Body.String(), "6 lb. russet potato") {
+		t.Errorf("shopping list = %s, want 6 lb. of potatoes", w.Body)
+	}
+
+	w = serve(ph, "GET", "/api/plans?id=week&view=cost")
+	var cost mealplan.Cost
+	if err := json.Unmarshal(w.Body.Bytes(), &cost); err != nil {
+		t.Fatal(err)
+	}
+	if cost.Total != nil || len(cost.Unpriced) != 2 {
+		t.Errorf("cost = %+v, want two unpriced meals", cost)
+	}
+
+	w = serve(ph, "GET", "/api/plans?id=week&view=ics")
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") || strings.Count(w.Body.String(), "BEGIN:VEVENT") != 2 {
+		t.Errorf("got %s: %s", w.Header().Get("Content-Type"), w.Body)
+	}
+
+	tests := []struct {
+		method, target, body string
+		status               int
+	}{
+		{"POST", "/api/plans", plan, http.StatusBadRequest},
+		{"POST", "/api/plans", `{"id": "../bad"}`, http.StatusBadRequest},
+		{"POST", "/api/plans", `{"id": "other", "meals": [{"date": "tomorrow", "slot": "dinner", "recipeId": "30990"}]}`, http.StatusBadRequest},
+		{"PUT", "/api/plans?id=week", `{"id": "week", "meals": []}`, http.StatusOK},
+		{"PUT", "/api/plans?id=week", `{"id": "renamed", "meals": []}`, http.StatusBadRequest},
+		{"PUT", "/api/plans?id=unknown", `{"id": "unknown", "meals": []}`, http.StatusNotFound},
+		{"GET", "/api/plans?id=week&view=pdf", "", http.StatusBadRequest},
+		{"GET", "/api/plans?id=week&view=cost&from=monday", "", http.StatusBadRequest},
+		{"DELETE", "/api/plans?id=week", "", http.StatusOK},
+		{"DELETE", "/api/plans?id=week", "", http.StatusNotFound},
+		{"GET", "/api/plans?id=week", "", http.StatusNotFound},
+		{"PATCH", "/api/plans", "", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		if w = sendPlan(ph, test.method, test.target, test.body); w.Code != test.status {
+			t.Errorf("%s %s = %d, want %d: %s", test.method, test.target, w.Code, test.status, w.Body)
+		}
+	}
+
+	if w = serve(ph, "GET", "/api/plans"); strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("GET /api/plans = %s, want no plans", w.Body)
+	}
+}
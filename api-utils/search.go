@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+const (
+	// DefaultPageSize is the number of recipes returned by a search if no limit is given.
+	DefaultPageSize = 20
+	// MaxPageSize is the largest limit a search accepts.
+	MaxPageSize = 100
+)
+
+// SearchHandler serves GET requests searching the store's recipes. See ParseQuery for the
+// supported parameters. The response looks like:
+//
+//	{"total": 42, "page": 1, "limit": 20, "recipes": [...]}
+type SearchHandler struct {
+	Store store.Searcher
+}
+
+type searchPage struct {
+	Total   int              `json:"total"`
+	Page    int              `json:"page"`
+	Limit   int              `json:"limit"`
+	Recipes []*models.Recipe `json:"recipes"`
+}
+
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Error: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := ParseQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := h.Store.Search(r.Context(), q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(searchPage{res.Total, q.Offset/q.Limit + 1, q.Limit, res.Recipes})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// ParseQuery reads a search from the query parameters:
+//   - q: words that must appear in the name, ingredients or instructions
+//   - ingredients: ingredients that the recipe must use, comma separated or repeated
+//   - exclude: ingredients that the recipe must not use, comma separated or repeated
+//   - maxCost: the most a serving may cost, eg. "2.50" or "$2.50"
+//   - maxTime: the longest the recipe may take, in minutes or as a duration (eg. "PT1H" or "1h")
+//   - sort: "name" (default), "time" or "cost", prefixed with "-" to reverse the order
+//   - page: the page of results to return, starting at 1
+//   - limit: the number of results per page, DefaultPageSize by default and at most MaxPageSize
+func ParseQuery(query url.Values) (store.Query, error) {
+	q := store.Query{
+		Text:    query.Get("q"),
+		Include: listParam(query, "ingredients"),
+		Exclude: listParam(query, "exclude"),
+		Limit:   DefaultPageSize,
+	}
+
+	if s := query.Get("maxCost"); s != "" {
+		// a bare number is taken to be in dollars
+		if s[0] >= '0' && s[0] <= '9' || s[0] == '.' {
+			s = "$" + s
+		}
+		cost, err := models.ParseMoney(s)
+		if err != nil || cost.Cents <= 0 {
+			return q, errors.New("maxCost must be a positive price, eg. 2.50")
+		}
+		q.MaxCostPerServing = cost.Cents
+	}
+
+	if s := query.Get("maxTime"); s != "" {
+		if minutes, err := strconv.ParseFloat(s, 64); err == nil {
+			q.MaxTime = models.Duration(minutes * float64(time.Minute))
+		} else if q.MaxTime, err = models.ParseDuration(s); err != nil {
+			return q, errors.New("maxTime must be a number of minutes or a duration, eg. PT1H")
+		}
+		if q.MaxTime <= 0 {
+			return q, errors.New("maxTime must be positive")
+		}
+	}
+
+	var err error
+	if q.Sort, q.Descending, err = store.ParseSortKey(query.Get("sort")); err != nil {
+		return q, err
+	}
+
+	if s := query.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 1 || q.Limit > MaxPageSize {
+			return q, errors.New("limit must be between 1 and " + strconv.Itoa(MaxPageSize))
+		}
+	}
+	if s := query.Get("page"); s != "" {
+		page, err := strconv.Atoi(s)
+		if err != nil || page < 1 {
+			return q, errors.New("page must be a number from 1")
+		}
+		q.Offset = (page - 1) * q.Limit
+	}
+	return q, nil
+}
+
+// listParam returns every value of a parameter that can be repeated or comma separated, eg.
+// "?ingredients=garlic,butter&ingredients=bread". Empty values are dropped.
+func listParam(query url.Values, key string) []string {
+	var list []string
+	for _, value := range query[key] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
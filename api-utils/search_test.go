@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/store"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  store.Query
+	}{
+		{"", store.Query{Sort: store.SortName, Limit: 20}},
+		{
+			"q=chili&ingredients=garlic,+black+beans&ingredients=onion&exclude=meat",
+			store.Query{
+				Text:    "chili",
+				Include: []string{"garlic", "black beans", "onion"},
+				Exclude: []string{"meat"},
+				Sort:    store.SortName,
+				Limit:   20,
+			},
+		},
+		{"maxCost=2.5&maxTime=45", store.Query{MaxCostPerServing: 250, MaxTime: models.Duration(45 * time.Minute), Sort: store.SortName, Limit: 20}},
+		{"maxCost=$1&maxTime=PT1H30M", store.Query{MaxCostPerServing: 100, MaxTime: models.Duration(90 * time.Minute), Sort: store.SortName, Limit: 20}},
+		{"sort=-cost&page=3&limit=10", store.Query{Sort: store.SortCost, Descending: true, Offset: 20, Limit: 10}},
+	}
+	for _, test := range tests {
+		values, _ := url.ParseQuery(test.query)
+		got, err := ParseQuery(values)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", test.query, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("ParseQuery(%q) (-want +got):\n%s", test.query, diff)
+		}
+	}
+
+	for _, query := range []string{"maxCost=cheap", "maxCost=0", "maxTime=soon", "maxTime=-5", "sort=rating", "page=0", "limit=500"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := ParseQuery(values); err == nil {
+			t.Errorf("ParseQuery(%q) succeeded, want an error", query)
+		}
+	}
+}
+
+func TestSearchHandler(t *testing.T) {
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Garlic Toast", "Garlic Knots", "Vegetarian Chili"} {
+		rcp := &models.Recipe{ID: name, Name: name, URL: "https://www.budgetbytes.com/" + url.PathEscape(name) + "/"}
+		if err = s.Insert(context.Background(), rcp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &SearchHandler{Store: s}
+
+	w := serve(h, "GET", "/api/recipes?q=garlic&limit=1&page=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var page searchPage
+	if err = json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.Page != 2 || page.Limit != 1 || len(page.Recipes) != 1 || page.Recipes[0].Name != "Garlic Toast" {
+		t.Errorf("got %+v, want the second of 2 recipes", page)
+	}
+
+	if w = serve(h, "GET", "/api/recipes?limit=abc"); w.Code != http.StatusBadRequest {
+		t.Errorf("status with a bad limit = %d, want 400", w.Code)
+	}
+	if w = serve(h, "POST", "/api/recipes"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
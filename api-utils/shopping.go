@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/shopping"
+	"github.com/ejacobg/recipe-parser/store"
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+// ShoppingHandler serves GET requests for a shopping list made from stored recipes (see
+// shopping.New). The parameters are:
+//   - recipe: a recipe's name, URL or ID, repeated for each recipe. It may be followed by ":factor"
+//     to scale the recipe, or "@servings" to make that many servings (see shopping.ParsePick).
+//   - format: "json" (default), "text" or "markdown"
+//   - units: "metric" or "us" gives every amount in that system
+type ShoppingHandler struct {
+	Store store.RecipeStore
+}
+
+func (h *ShoppingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Error: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	picks := query["recipe"]
+	if len(picks) == 0 {
+		http.Error(w, "Error: no recipe given", http.StatusBadRequest)
+		return
+	}
+	format := shopping.JSON
+	system := units.None
+	var err error
+	if query.Has("format") {
+		if format, err = shopping.ParseFormat(query.Get("format")); err != nil {
+			http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if query.Has("units") {
+		if system, err = units.ParseSystem(query.Get("units")); err != nil {
+			http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var recipes []*models.Recipe
+	for _, s := range picks {
+		pick, err := shopping.ParsePick(s)
+		if err != nil {
+			http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		rcp, err := FindRecipe(r.Context(), h.Store, pick.Recipe)
+		if err == store.ErrNotFound {
+			http.Error(w, "Error: recipe not found: "+pick.Recipe, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err = pick.Apply(rcp); err != nil {
+			http.Error(w, "Error: "+pick.Recipe+": "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		recipes = append(recipes, rcp)
+	}
+
+	var b bytes.Buffer
+	if err := shopping.New(recipes, system).Write(&b, format); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Write(b.Bytes())
+}
+
+// FindRecipe looks up a stored recipe by its ID, its URL (or URL name, see Canonicalize), or its
+// name if the store supports it. Recipes that haven't been saved aren't fetched.
+func FindRecipe(ctx context.Context, s store.RecipeStore, name string) (*models.Recipe, error) {
+	if name == "" {
+		return nil, errors.New("no recipe given")
+	}
+	rcp, err := s.GetByID(ctx, name)
+	if err == store.ErrNotFound {
+		rcp, err = s.GetByURL(ctx, Canonicalize(name))
+	}
+	if named, ok := s.(store.NameGetter); ok && err == store.ErrNotFound {
+		rcp, err = named.GetByName(ctx, name)
+	}
+	return rcp, err
+}
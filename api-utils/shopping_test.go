@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ejacobg/recipe-parser/shopping"
+)
+
+func TestShoppingHandler(t *testing.T) {
+	h, _ := testHandler(t)
+	if w := serve(h, "POST", "/api/recipe?name=slow-cooker-mashed-potatoes"); w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d: %s", w.Code, w.Body)
+	}
+	sh := &ShoppingHandler{Store: h.Store}
+
+	// the same recipe twice, by ID and by name
+	w := serve(sh, "GET", "/api/shopping?recipe=30990&recipe=slow-cooker-mashed-potatoes@12")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var list shopping.List
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Sections) != 1 || len(list.Sections[0].Items) != 1 {
+		t.Fatalf("got %+v, want a single item", list)
+	}
+	if got := list.Sections[0].Items[0].Amounts; len(got) != 1 || got[0].String() != "9 lb." {
+		t.Errorf("potatoes = %v, want 9 lb.", got)
+	}
+
+	w = serve(sh, "GET", "/api/shopping?recipe=30990&format=markdown&units=metric")
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") || !strings.Contains(w.Body.String(), "- [ ] 1.4 kg russet potato\n") {
+		t.Errorf("got %s: %s", w.Header().Get("Content-Type"), w.Body)
+	}
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/api/shopping", http.StatusBadRequest},
+		{"/api/shopping?recipe=unknown", http.StatusNotFound},
+		{"/api/shopping?recipe=30990:0", http.StatusBadRequest},
+		{"/api/shopping?recipe=30990&format=pdf", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if w = serve(sh, "GET", test.target); w.Code != test.status {
+			t.Errorf("GET %s = %d, want %d", test.target, w.Code, test.status)
+		}
+	}
+}
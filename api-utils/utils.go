@@ -5,43 +5,72 @@ import (
 	"errors"
 	"net/http"
 	"net/url"
-	"strings"
+	"strconv"
 
 	"github.com/ejacobg/recipe-parser/models"
 	"github.com/ejacobg/recipe-parser/recipe"
-	"golang.org/x/net/html"
+	"github.com/ejacobg/recipe-parser/sites"
+	"github.com/ejacobg/recipe-parser/units"
 )
 
 // If the name is correct, then the canonicalized version should match the Recipe.URL field.
+// Full URLs are also accepted, see sites.Canonicalize.
 func Canonicalize(name string) string {
-	// Convert from "%2D" to "-"
-	esc, err := url.PathUnescape(name)
-	if err != nil {
-		// Ignore any failures
-		esc = name
-	}
-	esc = strings.TrimSpace(esc)
-	esc = strings.TrimSuffix(esc, "/")
-	return "https://www.budgetbytes.com/" + strings.ToLower(esc) + "/"
+	return sites.Canonicalize(name)
 }
 
 // "source" should be a canonicalized name.
-func RecipeFromSource(source string) (*models.Recipe, error) {
-	resp, err := http.Get(source)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// The page is parsed by whichever site adapter is registered for the URL's host.
+func RecipeFromSource(source string) (*recipe.Result, error) {
+	return sites.Fetch(source)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("non-OK HTTP status from budgetbytes.com: " + resp.Status)
+// ApplyOptions applies the optional query parameters that change how a recipe is returned:
+//   - scale: multiplies the recipe by the given factor, eg. 2 to double it
+//   - servings: scales the recipe to make the given number of servings
+//   - units: "metric" or "us" converts the ingredient amounts to that system
+//
+// Only one of scale and servings may be given. The recipe is scaled before its units are converted.
+func ApplyOptions(query url.Values, rcp *models.Recipe) error {
+	if query.Has("scale") && query.Has("servings") {
+		return errors.New("only one of scale and servings may be given")
 	}
-	doc, err := html.Parse(resp.Body)
-	if err != nil {
-		return nil, err
+	if query.Has("scale") {
+		factor, err := strconv.ParseFloat(query.Get("scale"), 64)
+		if err != nil {
+			return errors.New("scale must be a number")
+		}
+		if err = rcp.Scale(factor); err != nil {
+			return err
+		}
 	}
+	if query.Has("servings") {
+		servings, err := strconv.ParseFloat(query.Get("servings"), 64)
+		if err != nil {
+			return errors.New("servings must be a number")
+		}
+		if err = rcp.ScaleToServings(servings); err != nil {
+			return err
+		}
+	}
+	if values, ok := query["units"]; ok && len(values) > 0 {
+		system, err := units.ParseSystem(values[0])
+		if err != nil {
+			return err
+		}
+		rcp.ConvertUnits(system)
+	}
+	return nil
+}
 
-	return recipe.FromHTML(doc)
+// WriteQueriedRecipe applies the query parameters from the request to the recipe before writing it.
+// Invalid parameters are reported to the client as a 400 Bad Request.
+func WriteQueriedRecipe(w http.ResponseWriter, query url.Values, rcp *models.Recipe) error {
+	if err := ApplyOptions(query, rcp); err != nil {
+		http.Error(w, "Error: "+err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	return WriteRecipe(w, rcp, http.StatusOK)
 }
 
 func WriteRecipe(w http.ResponseWriter, rcp *models.Recipe, status int) error {
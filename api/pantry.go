@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	utils "github.com/ejacobg/recipe-parser/api-utils"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// Pantry ranks the saved recipes by how many of their ingredients are on hand, using MongoDB's
+// Data API. See utils.PantryHandler for the supported parameters.
+func Pantry(w http.ResponseWriter, r *http.Request) {
+	s := &store.DataAPI{
+		Key:        os.Getenv("DATA_API_KEY"),
+		DataSource: "Cluster0",
+		Database:   os.Getenv("DB_NAME"),
+		Collection: "recipes",
+	}
+	(&utils.PantryHandler{Store: s}).ServeHTTP(w, r)
+}
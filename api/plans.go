@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	utils "github.com/ejacobg/recipe-parser/api-utils"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// Plans creates, edits and uses meal plans, using MongoDB's Data API.
+// See utils.PlanHandler for the supported methods and parameters.
+func Plans(w http.ResponseWriter, r *http.Request) {
+	s := &store.DataAPI{
+		Key:        os.Getenv("DATA_API_KEY"),
+		DataSource: "Cluster0",
+		Database:   os.Getenv("DB_NAME"),
+		Collection: "recipes",
+	}
+	(&utils.PlanHandler{Store: s}).ServeHTTP(w, r)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	utils "github.com/ejacobg/recipe-parser/api-utils"
+	"github.com/ejacobg/recipe-parser/store"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Recipes searches the saved recipes in MongoDB, using the Go driver.
+// See utils.ParseQuery for the supported parameters.
+func Recipes(w http.ResponseWriter, r *http.Request) {
+	uri := os.Getenv("MONGODB_URI")
+	client, err := mongo.Connect(
+		context.TODO(), options.Client().ApplyURI(uri),
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer client.Disconnect(context.TODO())
+
+	db := os.Getenv("DB_NAME")
+	coll := client.Database(db).Collection("recipes")
+	(&utils.SearchHandler{Store: store.NewMongo(coll)}).ServeHTTP(w, r)
+}
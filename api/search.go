@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	utils "github.com/ejacobg/recipe-parser/api-utils"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// Search is functionally the same as /api/recipes, except it uses MongoDB's Data API.
+func Search(w http.ResponseWriter, r *http.Request) {
+	s := &store.DataAPI{
+		Key:        os.Getenv("DATA_API_KEY"),
+		DataSource: "Cluster0",
+		Database:   os.Getenv("DB_NAME"),
+		Collection: "recipes",
+	}
+	(&utils.SearchHandler{Store: s}).ServeHTTP(w, r)
+}
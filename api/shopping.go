@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	utils "github.com/ejacobg/recipe-parser/api-utils"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// Shopping makes a shopping list from saved recipes, using MongoDB's Data API.
+// See utils.ShoppingHandler for the supported parameters.
+func Shopping(w http.ResponseWriter, r *http.Request) {
+	s := &store.DataAPI{
+		Key:        os.Getenv("DATA_API_KEY"),
+		DataSource: "Cluster0",
+		Database:   os.Getenv("DB_NAME"),
+		Collection: "recipes",
+	}
+	(&utils.ShoppingHandler{Store: s}).ServeHTTP(w, r)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/ejacobg/recipe-parser/batch"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+func init() {
+	commands["batch"] = command{
+		usage: "recipe-parser batch [-workers n] [-interval 1s] [-report file.jsonl] [-skip-existing] [-strict] [-dbpath dir] [file|-]",
+		run:   runBatch,
+	}
+}
+
+// runBatch fetches every recipe name or URL listed in a file (or stdin), one per line, and saves
+// them. A JSON report line is written for each recipe as it finishes.
+func runBatch(args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	workers := fs.Int("workers", batch.DefaultWorkers, "number of recipes to fetch at the same time")
+	interval := fs.Duration("interval", batch.DefaultInterval, "least time between two requests to the same website")
+	reportPath := fs.String("report", "", "write the JSON Lines report to this file instead of stdout")
+	skipExisting := fs.Bool("skip-existing", false, "don't fetch recipes that are already saved")
+	strict := fs.Bool("strict", false, "fail any recipe with a field that can't be parsed")
+	dbPath := dbFlag(fs)
+	setupFetch := fetchFlags(fs)
+	fs.Parse(args)
+	if err := setupFetch(); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("usage: recipe-parser batch [flags] [file|-]")
+	}
+	name := "-"
+	if fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+	inputs, err := readLines(name)
+	if err != nil {
+		return err
+	}
+
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close(db)
+
+	out := os.Stdout
+	if *reportPath != "" {
+		if out, err = os.Create(*reportPath); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	enc := json.NewEncoder(out)
+
+	b := &batch.Batch{Store: db, Workers: *workers, Interval: *interval, SkipExisting: *skipExisting}
+	if *strict {
+		b.Options = append(b.Options, recipe.Strict())
+	}
+	// Stop starting new recipes on Ctrl+C, but still report the ones in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	counts := make(map[batch.Status]int)
+	b.Run(ctx, inputs, func(e batch.Entry) {
+		counts[e.Status]++
+		if err = enc.Encode(e); err != nil {
+			log.Println("Error: writing report:", err)
+		}
+		switch e.Status {
+		case batch.Failed:
+			log.Println("Error:", e.Input+":", e.Error)
+		case batch.OK:
+			for _, w := range e.Warnings {
+				log.Println("Warning:", e.Input+":", w)
+			}
+		}
+	})
+	log.Printf("%d saved, %d failed, %d skipped", counts[batch.OK], counts[batch.Failed], counts[batch.Skipped])
+	if counts[batch.Failed] > 0 {
+		return fmt.Errorf("%d of %d recipes failed", counts[batch.Failed], len(inputs))
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Package batch fetches many recipes at once, using a pool of workers that take turns on each
+// website so that no site is sent requests faster than a set rate.
+package batch
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/fetch"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/sites"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// Status is the outcome of one input.
+type Status string
+
+const (
+	// OK means the recipe was fetched and saved.
+	OK Status = "ok"
+	// Failed means the recipe couldn't be fetched, parsed or saved. See Entry.Error.
+	Failed Status = "failed"
+	// Skipped means the recipe wasn't fetched, because it was already saved or was given twice.
+	Skipped Status = "skipped"
+)
+
+// Entry is one line of the report, describing what happened to one input.
+type Entry struct {
+	// Input is the recipe name or URL as it was given.
+	Input string `json:"input"`
+	// URL is the canonicalized URL that was fetched.
+	URL      string           `json:"url"`
+	Status   Status           `json:"status"`
+	ID       string           `json:"id,omitempty"`
+	Name     string           `json:"name,omitempty"`
+	Strategy recipe.Strategy  `json:"strategy,omitempty"`
+	Warnings []recipe.Warning `json:"warnings,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// DefaultWorkers is the number of recipes fetched at the same time if Batch.Workers isn't set.
+const DefaultWorkers = 4
+
+// DefaultInterval is a polite delay between requests to the same website.
+const DefaultInterval = time.Second
+
+// Batch fetches recipes and saves them to Store.
+type Batch struct {
+	Store store.RecipeStore
+	// Fetch downloads and parses a recipe from its canonicalized URL. Defaults to sites.FetchWith
+	// using fetch.Default.
+	Fetch func(ctx context.Context, source string, opts ...recipe.Option) (*recipe.Result, error)
+	// Options are passed on to Fetch, eg. recipe.Strict().
+	Options []recipe.Option
+	// Workers is the most recipes fetched at the same time.
+	Workers int
+	// Interval is the least time between two requests to the same host. Zero means no limit.
+	Interval time.Duration
+	// SkipExisting skips recipes whose URL is already in the store, rather than fetching them again.
+	SkipExisting bool
+
+	// saving makes sure only one worker writes to the store at a time, since store.Save's Insert
+	// followed by Replace isn't atomic.
+	saving sync.Mutex
+	limits hostLimiter
+}
+
+// Run fetches every input (a recipe name or URL, see sites.Canonicalize), calling report once for
+// each in the order they finish. report is never called by two workers at once.
+// Inputs that haven't been started when ctx is cancelled are reported as failed.
+func (b *Batch) Run(ctx context.Context, inputs []string, report func(Entry)) {
+	workers := b.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	b.limits = hostLimiter{interval: b.Interval, next: make(map[string]time.Time)}
+
+	var reporting sync.Mutex
+	send := func(e Entry) {
+		reporting.Lock()
+		defer reporting.Unlock()
+		report(e)
+	}
+
+	jobs := make(chan Entry)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				send(b.do(ctx, e))
+			}
+		}()
+	}
+
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		e := Entry{Input: input, URL: sites.Canonicalize(input)}
+		if seen[e.URL] {
+			e.Status, e.Error = Skipped, "duplicate"
+			send(e)
+			continue
+		}
+		seen[e.URL] = true
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// do fetches and saves one recipe.
+func (b *Batch) do(ctx context.Context, e Entry) Entry {
+	fail := func(err error) Entry {
+		e.Status, e.Error = Failed, err.Error()
+		return e
+	}
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+	if b.SkipExisting {
+		if rcp, err := b.Store.GetByURL(ctx, e.URL); err == nil {
+			e.Status, e.ID, e.Name, e.Error = Skipped, rcp.ID, rcp.Name, "already saved"
+			return e
+		}
+	}
+
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return fail(err)
+	}
+	if err = b.limits.wait(ctx, u.Host); err != nil {
+		return fail(err)
+	}
+	get := b.Fetch
+	if get == nil {
+		get = fetchDefault
+	}
+	res, err := get(ctx, e.URL, b.Options...)
+	if err != nil {
+		return fail(err)
+	}
+	e.ID, e.Name, e.Strategy, e.Warnings = res.Recipe.ID, res.Recipe.Name, res.Strategy, res.Warnings
+
+	b.saving.Lock()
+	defer b.saving.Unlock()
+	// Fetching a recipe again updates the saved copy.
+	if err = store.Save(ctx, b.Store, res.Recipe, res.Snapshot); err != nil {
+		return fail(err)
+	}
+	e.Status = OK
+	return e
+}
+
+// fetchDefault is the default Batch.Fetch.
+func fetchDefault(ctx context.Context, source string, opts ...recipe.Option) (*recipe.Result, error) {
+	return sites.FetchWith(ctx, fetch.Default, source, opts...)
+}
+
+// hostLimiter spaces out the requests to each host.
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	// next holds the earliest time the next request to each host may start.
+	next map[string]time.Time
+}
+
+// wait blocks until a request can be sent to the host, reserving the slot so that the next caller
+// waits a further interval.
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l.interval <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	l.next[host] = start.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
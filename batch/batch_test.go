@@ -0,0 +1,148 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// fakeFetch makes up a recipe for every URL except those containing "broken", and records how
+// many fetches ran at the same time.
+type fakeFetch struct {
+	mu            sync.Mutex
+	running, most int
+	starts        []time.Time
+	delay         time.Duration
+}
+
+func (f *fakeFetch) fetch(_ context.Context, source string, _ ...recipe.Option) (*recipe.Result, error) {
+	f.mu.Lock()
+	f.running++
+	if f.running > f.most {
+		f.most = f.running
+	}
+	f.starts = append(f.starts, time.Now())
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.running--
+		f.mu.Unlock()
+	}()
+
+	time.Sleep(f.delay)
+	if strings.Contains(source, "broken") {
+		return nil, errors.New("no recipe found at " + source)
+	}
+	name := strings.Trim(source[strings.LastIndex(strings.TrimSuffix(source, "/"), "/"):], "/")
+	return &recipe.Result{
+		Recipe:   &models.Recipe{ID: name, Name: name, URL: source},
+		Strategy: recipe.JSONLD,
+		Warnings: []recipe.Warning{{Kind: recipe.MissingField, Field: "image"}},
+	}, nil
+}
+
+func run(t *testing.T, b *Batch, inputs []string) map[string]Entry {
+	t.Helper()
+	entries := make(map[string]Entry)
+	b.Run(context.Background(), inputs, func(e Entry) {
+		if _, ok := entries[e.Input]; ok {
+			t.Errorf("%s reported twice", e.Input)
+		}
+		entries[e.Input] = e
+	})
+	if len(entries) != len(inputs) {
+		t.Errorf("got %d entries, want %d", len(entries), len(inputs))
+	}
+	return entries
+}
+
+func TestRun(t *testing.T) {
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeFetch{delay: 10 * time.Millisecond}
+	b := &Batch{Store: s, Fetch: f.fetch, Workers: 2}
+
+	inputs := []string{
+		"soup",
+		"https://www.example.com/stew/",
+		"broken",
+		"https://www.example.com/chili/",
+		"https://www.example.com/pie/",
+	}
+	entries := run(t, b, inputs)
+	if f.most > 2 {
+		t.Errorf("%d fetches ran at once, want at most 2", f.most)
+	}
+	if e := entries["soup"]; e.Status != OK || e.URL != "https://www.budgetbytes.com/soup/" || len(e.Warnings) != 1 {
+		t.Errorf("soup = %+v", e)
+	}
+	if e := entries["broken"]; e.Status != Failed || e.Error == "" {
+		t.Errorf("broken = %+v, want an error", e)
+	}
+	if _, err := s.GetByURL(context.Background(), "https://www.example.com/chili/"); err != nil {
+		t.Errorf("chili wasn't saved: %v", err)
+	}
+
+	// saved recipes and repeated inputs aren't fetched again
+	b.SkipExisting = true
+	f.starts = nil
+	entries = run(t, b, []string{"soup", "cake", "Cake/"})
+	if e := entries["soup"]; e.Status != Skipped || e.ID != "soup" {
+		t.Errorf("soup = %+v, want it skipped", e)
+	}
+	if len(f.starts) != 1 {
+		t.Errorf("%d fetches, want only cake to be fetched", len(f.starts))
+	}
+	if e := entries["Cake/"]; e.Status != Skipped || e.Error != "duplicate" {
+		t.Errorf("Cake/ = %+v, want it skipped as a duplicate", e)
+	}
+}
+
+func TestRunRateLimit(t *testing.T) {
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeFetch{}
+	interval := 50 * time.Millisecond
+	b := &Batch{Store: s, Fetch: f.fetch, Workers: 4, Interval: interval}
+	run(t, b, []string{"a", "b", "c"})
+
+	if len(f.starts) != 3 {
+		t.Fatalf("%d fetches, want 3", len(f.starts))
+	}
+	for i := 1; i < len(f.starts); i++ {
+		// allow for timer imprecision
+		if gap := f.starts[i].Sub(f.starts[i-1]); gap < interval-5*time.Millisecond {
+			t.Errorf("fetch %d started %v after the last, want at least %v", i, gap, interval)
+		}
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := &fakeFetch{}
+	b := &Batch{Store: s, Fetch: f.fetch}
+	b.Run(ctx, []string{"a", "b"}, func(e Entry) {
+		if e.Status != Failed {
+			t.Errorf("%s = %+v, want it to fail", e.Input, e)
+		}
+	})
+	if len(f.starts) != 0 {
+		t.Errorf("%d fetches after cancelling, want none", len(f.starts))
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/fetch"
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/sites"
+	"github.com/ejacobg/recipe-parser/store"
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+// A command is a subcommand of recipe-parser, eg. "recipe-parser scale ...".
+// Each command parses its own flags from args (which doesn't include the command name).
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+// commands holds every subcommand, keyed by name. Commands add themselves in an init function.
+var commands = map[string]command{}
+
+// commandUsages returns the usage line of every command, sorted by name.
+func commandUsages() []string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var usages []string
+	for _, name := range names {
+		usages = append(usages, commands[name].usage)
+	}
+	return usages
+}
+
+// defaultDBPath is the directory of saved recipes, see store.Dir.
+const defaultDBPath = "./database/"
+
+// dbFlag adds the -dbpath flag to a command's flags.
+func dbFlag(fs *flag.FlagSet) *string {
+	return fs.String("dbpath", defaultDBPath, dbUsage)
+}
+
+const dbUsage = "directory of saved recipes, or a SQLite database (.db)"
+
+// fetchFlags adds the flags that set up how pages are downloaded (see fetch.Fetcher) to a
+// command's flags. Call the returned function once the flags are parsed to apply them to
+// fetch.Default.
+func fetchFlags(fs *flag.FlagSet) func() error {
+	cache := fs.String("cache", "", "keep downloaded pages in this directory, and only download them again if they've changed")
+	offline := fs.Bool("offline", false, "only read pages from the -cache directory, never from the network")
+	timeout := fs.Duration("timeout", fetch.DefaultTimeout, "give up on a request after this long")
+	retries := fs.Int("retries", fetch.DefaultRetries, "times to retry a request that failed with a network error, 5xx or 429 status")
+	userAgent := fs.String("user-agent", fetch.DefaultUserAgent, "User-Agent header sent to websites")
+	return func() error {
+		if *offline && *cache == "" {
+			return errors.New("-offline needs a -cache directory")
+		}
+		f := fetch.Default
+		f.Timeout, f.UserAgent, f.Offline, f.Retries = *timeout, *userAgent, *offline, *retries
+		if *retries == 0 {
+			f.Retries = -1
+		}
+		if *cache != "" {
+			f.Cache = &fetch.Cache{Dir: *cache}
+		}
+		return nil
+	}
+}
+
+// loadRecipe reads the recipe from a JSON file if given one. Otherwise the recipe is looked up in
+// the saved recipes by URL or name, and fetched from its website if it hasn't been saved.
+func loadRecipe(arg, dbPath string) (*models.Recipe, error) {
+	if strings.HasSuffix(arg, ".json") {
+		return recipe.FromJSON(arg)
+	}
+	source := sites.Canonicalize(arg)
+	if db, err := store.Open(dbPath); err == nil {
+		defer store.Close(db)
+		rcp, err := db.GetByURL(context.TODO(), source)
+		if named, ok := db.(store.NameGetter); ok && err == store.ErrNotFound {
+			rcp, err = named.GetByName(context.TODO(), arg)
+		}
+		if err == nil {
+			return rcp, nil
+		}
+	}
+
+	res, err := sites.Fetch(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range res.Warnings {
+		log.Println("Warning:", w)
+	}
+	return res.Recipe, nil
+}
+
+// convertUnits converts the recipe's ingredients to the named system. Nothing is done if the name
+// is empty.
+func convertUnits(r *models.Recipe, system string) error {
+	if system == "" {
+		return nil
+	}
+	s, err := units.ParseSystem(system)
+	if err != nil {
+		return err
+	}
+	r.ConvertUnits(s)
+	return nil
+}
+
+// printConverted prints a copy of the recipe with its ingredients converted to the named system,
+// leaving the recipe itself as it was.
+func printConverted(r *models.Recipe, system string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	converted := &models.Recipe{}
+	if err = json.Unmarshal(data, converted); err != nil {
+		return err
+	}
+	if err = convertUnits(converted, system); err != nil {
+		return err
+	}
+	return printRecipe(converted)
+}
+
+// printRecipe writes the recipe to stdout as indented JSON.
+func printRecipe(r *models.Recipe) error {
+	data, err := r.ToJSON()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ejacobg/recipe-parser/pantry"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+func init() {
+	commands["cook"] = command{
+		usage: "recipe-parser cook [-f pantry.txt] [-min fraction] [-n count] [-json] [-dbpath dir] [ingredient...]",
+		run:   cook,
+	}
+}
+
+// cook ranks the saved recipes by how many of their ingredients are on hand. Ingredients are given
+// as arguments (each may be a comma separated list), or one per line in a file.
+func cook(args []string) error {
+	fs := flag.NewFlagSet("cook", flag.ExitOnError)
+	file := fs.String("f", "", "file listing the ingredients on hand, one per line (\"-\" for stdin)")
+	min := fs.Float64("min", 0.5, "only show recipes with at least this fraction of their ingredients on hand")
+	count := fs.Int("n", 10, "show at most this many recipes, 0 for all")
+	asJSON := fs.Bool("json", false, "print the matches as JSON")
+	dbPath := dbFlag(fs)
+	fs.Parse(args)
+
+	var items []string
+	for _, arg := range fs.Args() {
+		items = append(items, strings.Split(arg, ",")...)
+	}
+	if *file != "" {
+		lines, err := readLines(*file)
+		if err != nil {
+			return err
+		}
+		items = append(items, lines...)
+	}
+	if len(items) == 0 {
+		return errors.New("no ingredients given")
+	}
+
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close(db)
+	recipes, err := db.List(context.TODO())
+	if err != nil {
+		return err
+	}
+	matches := pantry.New(items).Rank(recipes, *min)
+	if *count > 0 && len(matches) > *count {
+		matches = matches[:*count]
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(matches) == 0 {
+		fmt.Println("No recipes found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, m := range matches {
+		missing := strings.Join(m.Missing, ", ")
+		if missing == "" {
+			missing = "nothing"
+		}
+		fmt.Fprintf(w, "%3.0f%%\t%s\tmissing: %s\n", m.Coverage*100, m.Recipe.Name, missing)
+	}
+	return w.Flush()
+}
+
+// readLines returns the non-empty lines of a file, or of stdin if the name is "-". Lines starting
+// with "#" are skipped.
+func readLines(name string) ([]string, error) {
+	f := os.Stdin
+	if name != "-" {
+		var err error
+		if f, err = os.Open(name); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/batch"
+	"github.com/ejacobg/recipe-parser/crawl"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+func init() {
+	commands["crawl"] = command{
+		usage: "recipe-parser crawl [-delay 2s] [-checkpoint file] [-category path]... [-limit n] [-retry-failed] [-report file.jsonl] [-dbpath dir] [site-url]",
+		run:   runCrawl,
+	}
+}
+
+// listFlag collects a flag that may be given more than once.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// runCrawl imports every recipe on a website that hasn't been saved yet. The site defaults to
+// budgetbytes.com.
+func runCrawl(args []string) error {
+	fs := flag.NewFlagSet("crawl", flag.ExitOnError)
+	delay := fs.Duration("delay", crawl.DefaultDelay, "least time between two requests (the site's Crawl-delay is used if longer)")
+	checkpoint := fs.String("checkpoint", "", "save progress to this file, and resume from it if it exists")
+	var categories listFlag
+	fs.Var(&categories, "category", "archive page to find recipes on if the site has no sitemap, eg. /category/recipes/ (repeatable)")
+	limit := fs.Int("limit", 0, "fetch at most this many recipes (0 is no limit)")
+	retryFailed := fs.Bool("retry-failed", false, "fetch pages that failed in an earlier crawl again")
+	reportPath := fs.String("report", "", "write the JSON Lines report to this file instead of stdout")
+	strict := fs.Bool("strict", false, "fail any recipe with a field that can't be parsed")
+	dbPath := dbFlag(fs)
+	setupFetch := fetchFlags(fs)
+	fs.Parse(args)
+	if err := setupFetch(); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("usage: recipe-parser crawl [flags] [site-url]")
+	}
+	site := "https://www.budgetbytes.com/"
+	if fs.NArg() == 1 {
+		site = fs.Arg(0)
+	}
+
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close(db)
+
+	out := os.Stdout
+	if *reportPath != "" {
+		if out, err = os.Create(*reportPath); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	enc := json.NewEncoder(out)
+
+	c := &crawl.Crawler{
+		Site:        site,
+		Store:       db,
+		Delay:       *delay,
+		Categories:  categories,
+		Checkpoint:  *checkpoint,
+		Limit:       *limit,
+		RetryFailed: *retryFailed,
+		Logf:        log.Printf,
+	}
+	if *strict {
+		c.Options = append(c.Options, recipe.Strict())
+	}
+	// Ctrl+C stops the crawl after saving the checkpoint.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	counts := make(map[batch.Status]int)
+	err = c.Run(ctx, func(e batch.Entry) {
+		counts[e.Status]++
+		if err := enc.Encode(e); err != nil {
+			log.Println("Error: writing report:", err)
+		}
+		if e.Status == batch.Failed {
+			log.Println("Error:", e.Input+":", e.Error)
+		}
+	})
+	log.Printf("%d saved, %d failed, %d skipped", counts[batch.OK], counts[batch.Failed], counts[batch.Skipped])
+	if err == context.Canceled && *checkpoint != "" {
+		log.Println("Stopped, run the same command again to carry on")
+	}
+	return err
+}
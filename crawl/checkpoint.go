@@ -0,0 +1,69 @@
+package crawl
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/ejacobg/recipe-parser/batch"
+)
+
+// Checkpoint is the progress of a crawl, saved so that a stopped crawl can carry on without
+// reading the sitemaps again or refetching pages it has already tried.
+type Checkpoint struct {
+	// Found lists the recipe URLs that were discovered, in the order they were found.
+	Found []string `json:"found"`
+	// Tried holds the outcome of every URL that has been fetched. Failed URLs are only tried again
+	// if Crawler.RetryFailed is set.
+	Tried map[string]batch.Status `json:"tried"`
+	// Complete is set once every URL in Found has been tried. The next crawl starts by
+	// discovering the site's recipes again, to find any new ones.
+	Complete bool `json:"complete"`
+}
+
+// loadCheckpoint reads the checkpoint file. A missing file gives an empty checkpoint.
+func loadCheckpoint(name string) (*Checkpoint, error) {
+	cp := &Checkpoint{Tried: make(map[string]batch.Status)}
+	if name == "" {
+		return cp, nil
+	}
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	if cp.Tried == nil {
+		cp.Tried = make(map[string]batch.Status)
+	}
+	return cp, nil
+}
+
+// save writes the checkpoint to a temporary file first, so that stopping the crawl midway
+// through can't leave a half-written checkpoint.
+func (cp *Checkpoint) save(name string) error {
+	if name == "" {
+		return nil
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,397 @@
+// Package crawl finds every recipe on a website from its sitemaps (or from its category pages if
+// it has no sitemap), and imports the ones that haven't been saved yet. The crawler follows the
+// site's robots.txt, waits between requests, and can save its progress to resume later.
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/batch"
+	"github.com/ejacobg/recipe-parser/fetch"
+	"github.com/ejacobg/recipe-parser/parser"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/sites"
+	"github.com/ejacobg/recipe-parser/store"
+	"golang.org/x/net/html"
+)
+
+const (
+	// DefaultDelay is the time waited between requests if the site's robots.txt doesn't ask for more.
+	DefaultDelay = 2 * time.Second
+	// DefaultMaxPages is the most category pages read when looking for recipes without a sitemap.
+	DefaultMaxPages = 500
+	// saveEvery is how many recipes are tried between saving the checkpoint.
+	saveEvery = 10
+)
+
+// Crawler imports the recipes of one website. A Crawler makes one request at a time, and
+// shouldn't be run twice at once.
+type Crawler struct {
+	// Site is the home page of the website, eg. "https://www.budgetbytes.com/".
+	Site  string
+	Store store.RecipeStore
+	// Fetcher makes the requests. Defaults to fetch.Default. Its User-Agent is also the one looked
+	// for in robots.txt.
+	Fetcher *fetch.Fetcher
+	// Delay is the least time between two requests. The site's Crawl-delay is used if it is longer.
+	Delay time.Duration
+	// Categories are the archive pages (relative to Site, eg. "/category/recipes/") that recipes
+	// are found on if the site has no sitemap. Links under the same path are followed as further
+	// pages, up to MaxPages.
+	Categories []string
+	MaxPages   int
+	// IsRecipe reports whether a page found in a sitemap or on a category page may be a recipe.
+	// Defaults to IsRecipePath.
+	IsRecipe func(u *url.URL) bool
+	// Checkpoint is the file that progress is saved to. No progress is saved if it's empty.
+	Checkpoint string
+	// Limit is the most recipes to fetch in one run. Zero means no limit.
+	Limit int
+	// RetryFailed fetches the pages that failed in an earlier run again.
+	RetryFailed bool
+	// Options are passed on to recipe.FromHTML, eg. recipe.Strict().
+	Options []recipe.Option
+	// Logf is told what the crawler is doing, if set.
+	Logf func(format string, args ...interface{})
+
+	site   *url.URL
+	robots *Robots
+	delay  time.Duration
+	last   time.Time
+}
+
+// IsRecipePath is the default Crawler.IsRecipe. Most recipe sites (budgetbytes.com included) give
+// each recipe a single path segment, eg. "/slow-cooker-mashed-potatoes/", while categories, tags
+// and paginated archives are nested deeper.
+func IsRecipePath(u *url.URL) bool {
+	path := strings.Trim(u.Path, "/")
+	return path != "" && !strings.Contains(path, "/") && !strings.Contains(path, ".")
+}
+
+// Run discovers the site's recipes (or resumes from the checkpoint) and imports the ones that
+// aren't saved yet, calling report for each page fetched. Pages that robots.txt disallows are
+// reported as skipped without being fetched.
+func (c *Crawler) Run(ctx context.Context, report func(batch.Entry)) error {
+	if err := c.init(ctx); err != nil {
+		return err
+	}
+	cp, err := loadCheckpoint(c.Checkpoint)
+	if err != nil {
+		return fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	if cp.Found == nil || cp.Complete {
+		if cp.Found, err = c.Discover(ctx); err != nil {
+			return err
+		}
+		cp.Complete = false
+		if err = cp.save(c.Checkpoint); err != nil {
+			return err
+		}
+	} else {
+		c.logf("Resuming crawl, %d of %d pages tried", len(cp.Tried), len(cp.Found))
+	}
+
+	var queue []string
+	limited := false
+	for _, page := range cp.Found {
+		if status, ok := cp.Tried[page]; ok && (status != batch.Failed || !c.RetryFailed) {
+			continue
+		}
+		if _, err := c.Store.GetByURL(ctx, sites.Canonicalize(page)); err == nil {
+			continue
+		}
+		u, err := url.Parse(page)
+		if err != nil {
+			continue
+		}
+		if !c.robots.Allowed(u.RequestURI()) {
+			cp.Tried[page] = batch.Skipped
+			report(batch.Entry{Input: page, URL: page, Status: batch.Skipped, Error: "disallowed by robots.txt"})
+			continue
+		}
+		if c.Limit > 0 && len(queue) == c.Limit {
+			limited = true
+			break
+		}
+		queue = append(queue, page)
+	}
+	c.logf("Found %d recipes, %d to fetch", len(cp.Found), len(queue))
+
+	b := &batch.Batch{Store: c.Store, Fetch: c.fetchRecipe, Options: c.Options, Workers: 1}
+	var saveErr error
+	tried := 0
+	b.Run(ctx, queue, func(e batch.Entry) {
+		report(e)
+		// pages that were never fetched because the crawl was stopped are tried next time
+		if e.Status == batch.Failed && ctx.Err() != nil {
+			return
+		}
+		cp.Tried[e.Input] = e.Status
+		if tried++; tried%saveEvery == 0 && saveErr == nil {
+			saveErr = cp.save(c.Checkpoint)
+		}
+	})
+
+	cp.Complete = !limited && ctx.Err() == nil
+	if err = cp.save(c.Checkpoint); err != nil {
+		return err
+	}
+	if saveErr != nil {
+		return saveErr
+	}
+	return ctx.Err()
+}
+
+// init reads the site's robots.txt, if it has one.
+func (c *Crawler) init(ctx context.Context) error {
+	var err error
+	if c.site, err = url.Parse(c.Site); err != nil {
+		return err
+	}
+	if !c.site.IsAbs() || c.site.Host == "" {
+		return errors.New("site must be a full URL, eg. https://www.budgetbytes.com/")
+	}
+	if c.Fetcher == nil {
+		c.Fetcher = fetch.Default
+	}
+
+	body, err := c.read(ctx, c.resolve("/robots.txt"))
+	var statusErr *fetch.StatusError
+	switch {
+	case err == nil:
+		userAgent := c.Fetcher.UserAgent
+		if userAgent == "" {
+			userAgent = fetch.DefaultUserAgent
+		}
+		if c.robots, err = ParseRobots(strings.NewReader(body), userAgent); err != nil {
+			return fmt.Errorf("reading robots.txt: %w", err)
+		}
+	case errors.As(err, &statusErr) && statusErr.StatusCode < 500:
+		// no robots.txt, so everything is allowed
+		c.robots = &Robots{}
+	default:
+		// the site may be down, so don't assume everything is allowed
+		return fmt.Errorf("reading robots.txt: %w", err)
+	}
+	c.delay = c.Delay
+	if c.robots.Delay > c.delay {
+		c.delay = c.robots.Delay
+	}
+	return nil
+}
+
+// Discover lists the URLs of the site's recipes, from its sitemaps, or from its category pages if
+// the sitemaps don't list any.
+func (c *Crawler) Discover(ctx context.Context) ([]string, error) {
+	if c.site == nil {
+		if err := c.init(ctx); err != nil {
+			return nil, err
+		}
+	}
+	found := &found{seen: make(map[string]bool)}
+
+	sitemaps := c.robots.Sitemaps
+	if len(sitemaps) == 0 {
+		sitemaps = []string{c.resolve("/sitemap.xml"), c.resolve("/sitemap_index.xml")}
+	}
+	if err := c.readSitemaps(ctx, sitemaps, found); err != nil {
+		return nil, err
+	}
+	if len(found.urls) == 0 && len(c.Categories) > 0 {
+		c.logf("No recipes in the sitemaps, reading the category pages")
+		if err := c.readCategories(ctx, found); err != nil {
+			return nil, err
+		}
+	}
+	if len(found.urls) == 0 {
+		return nil, errors.New("no recipes found on " + c.site.Host)
+	}
+	return found.urls, nil
+}
+
+// found collects URLs without repeats, in the order they were found.
+type found struct {
+	urls []string
+	seen map[string]bool
+}
+
+func (f *found) add(u string) {
+	if !f.seen[u] {
+		f.seen[u] = true
+		f.urls = append(f.urls, u)
+	}
+}
+
+// readSitemaps reads the sitemaps and any sitemaps they list. Missing sitemaps are skipped.
+func (c *Crawler) readSitemaps(ctx context.Context, queue []string, found *found) error {
+	visited := make(map[string]bool)
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+		if visited[loc] {
+			continue
+		}
+		visited[loc] = true
+
+		body, err := c.read(ctx, loc)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.logf("Skipping sitemap %s: %v", loc, err)
+			continue
+		}
+		sitemaps, pages, err := parseSitemap(strings.NewReader(body))
+		if err != nil {
+			c.logf("Skipping sitemap %s: %v", loc, err)
+			continue
+		}
+		queue = append(queue, sitemaps...)
+		for _, page := range pages {
+			if u, ok := c.recipeURL(page); ok {
+				found.add(u)
+			}
+		}
+	}
+	return nil
+}
+
+// readCategories follows the category pages (and the pages under them, eg. "/category/recipes/page/2/")
+// collecting links to recipes.
+func (c *Crawler) readCategories(ctx context.Context, found *found) error {
+	maxPages := c.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	var prefixes, queue []string
+	for _, category := range c.Categories {
+		u := c.resolve(category)
+		prefixes = append(prefixes, u)
+		queue = append(queue, u)
+	}
+	visited := make(map[string]bool)
+	for read := 0; len(queue) > 0 && read < maxPages; {
+		page := queue[0]
+		queue = queue[1:]
+		if visited[page] {
+			continue
+		}
+		visited[page] = true
+		if u, err := url.Parse(page); err != nil || !c.robots.Allowed(u.RequestURI()) {
+			continue
+		}
+
+		read++
+		body, err := c.read(ctx, page)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.logf("Skipping category page %s: %v", page, err)
+			continue
+		}
+		doc, err := html.Parse(strings.NewReader(body))
+		if err != nil {
+			continue
+		}
+		base, _ := url.Parse(page)
+		for _, a := range parser.QuerySelectorAll(doc, "a[href]") {
+			link, err := base.Parse(parser.GetAttribute(a, "href"))
+			if err != nil || !c.sameSite(link) {
+				continue
+			}
+			link.Fragment, link.RawQuery = "", ""
+			href := link.String()
+			if hasAnyPrefix(href, prefixes) {
+				queue = append(queue, href)
+			} else if u, ok := c.recipeURL(href); ok {
+				found.add(u)
+			}
+		}
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// recipeURL checks that the URL is on the site and may be a recipe.
+func (c *Crawler) recipeURL(s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil || !c.sameSite(u) {
+		return "", false
+	}
+	isRecipe := c.IsRecipe
+	if isRecipe == nil {
+		isRecipe = IsRecipePath
+	}
+	return u.String(), isRecipe(u)
+}
+
+// sameSite reports whether the URL is on the crawled site. "www." is ignored, as sites.Lookup does.
+func (c *Crawler) sameSite(u *url.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(u.Host), "www.") == strings.TrimPrefix(strings.ToLower(c.site.Host), "www.")
+}
+
+func (c *Crawler) resolve(path string) string {
+	u, err := c.site.Parse(path)
+	if err != nil {
+		return path
+	}
+	return u.String()
+}
+
+func (c *Crawler) logf(format string, args ...interface{}) {
+	if c.Logf != nil {
+		c.Logf(format, args...)
+	}
+}
+
+// read returns the body of the page.
+func (c *Crawler) read(ctx context.Context, source string) (string, error) {
+	page, err := c.get(ctx, source)
+	if err != nil {
+		return "", err
+	}
+	return string(page.Body), nil
+}
+
+// get downloads the page, waiting until the delay since the last request has passed.
+func (c *Crawler) get(ctx context.Context, source string) (*fetch.Response, error) {
+	if wait := time.Until(c.last.Add(c.delay)); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+	page, err := c.Fetcher.Get(ctx, source)
+	// pages from the cache only count as requests if they had to be revalidated
+	if page == nil || !page.FromCache || page.Revalidated {
+		c.last = time.Now()
+	}
+	return page, err
+}
+
+// fetchRecipe is used as batch.Batch.Fetch, so that recipe pages are fetched like every other page.
+func (c *Crawler) fetchRecipe(ctx context.Context, source string, opts ...recipe.Option) (*recipe.Result, error) {
+	page, err := c.get(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return sites.ParsePage(page, opts...)
+}
@@ -0,0 +1,254 @@
+package crawl
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/batch"
+	"github.com/ejacobg/recipe-parser/fetch"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// testSite serves the files in test-data/site, with "HOST" replaced by the server's URL. Pages
+// are read from test-data/site/pages, eg. "/category/recipes/" is pages/category-recipes.html.
+// Every file has an ETag, so that cached files are answered with 304 Not Modified.
+type testSite struct {
+	*httptest.Server
+	noSitemaps bool
+
+	mu       sync.Mutex
+	requests []string
+	// times and notModified record when each request was made, and how many were answered with 304.
+	times       []time.Time
+	notModified int
+}
+
+func newTestSite(t *testing.T, noSitemaps bool) *testSite {
+	site := &testSite{noSitemaps: noSitemaps}
+	site.Server = httptest.NewServer(site)
+	t.Cleanup(site.Close)
+	return site
+}
+
+func (s *testSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	s.times = append(s.times, time.Now())
+	s.mu.Unlock()
+
+	if !strings.HasPrefix(r.UserAgent(), "recipe-parser/") {
+		http.Error(w, "no user agent", http.StatusForbidden)
+		return
+	}
+	name := filepath.Join("test-data", "site", r.URL.Path)
+	if !strings.Contains(r.URL.Path, ".") {
+		name = filepath.Join("test-data", "site", "pages", strings.ReplaceAll(strings.Trim(r.URL.Path, "/"), "/", "-")+".html")
+	}
+	if s.noSitemaps && strings.HasSuffix(name, ".xml") {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	etag := `"` + name + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		s.mu.Lock()
+		s.notModified++
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if s.noSitemaps && strings.HasSuffix(name, "robots.txt") {
+		data = bytes.ReplaceAll(data, []byte("Sitemap:"), []byte("# Sitemap:"))
+	}
+	w.Write(bytes.ReplaceAll(data, []byte("HOST"), []byte(s.URL)))
+}
+
+// fetched returns how many times each path was requested since the last call.
+func (s *testSite) fetched() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, path := range s.requests {
+		counts[path]++
+	}
+	s.requests = nil
+	return counts
+}
+
+func crawl(t *testing.T, c *Crawler) map[string]batch.Entry {
+	t.Helper()
+	entries := make(map[string]batch.Entry)
+	err := c.Run(context.Background(), func(e batch.Entry) {
+		entries[strings.TrimPrefix(e.Input, c.Site)] = e
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func savedURLs(t *testing.T, s store.RecipeStore) []string {
+	t.Helper()
+	recipes, err := s.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, rcp := range recipes {
+		urls = append(urls, rcp.URL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestCrawl(t *testing.T) {
+	site := newTestSite(t, false)
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Crawler{Site: site.URL, Store: s, Checkpoint: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	entries := crawl(t, c)
+	if len(entries) != 4 {
+		t.Errorf("got %d entries, want 4: %v", len(entries), entries)
+	}
+	for page, status := range map[string]batch.Status{
+		"/lentil-soup/":      batch.OK,
+		"/beef-stew/":        batch.OK,
+		"/about/":            batch.Failed,
+		"/members-only-pie/": batch.Skipped,
+	} {
+		if got := entries[page].Status; got != status {
+			t.Errorf("%s = %s, want %s", page, got, status)
+		}
+	}
+	want := []string{site.URL + "/beef-stew/", site.URL + "/lentil-soup/"}
+	if got := savedURLs(t, s); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+	fetched := site.fetched()
+	if fetched["/members-only-pie/"] != 0 || fetched["/category/recipes/"] != 0 || fetched["/"] != 0 {
+		t.Errorf("fetched %v, want no disallowed, category or home pages", fetched)
+	}
+	if fetched["/missing-sitemap.xml"] != 1 || fetched["/lentil-soup/"] != 1 {
+		t.Errorf("fetched %v, want every sitemap and recipe once", fetched)
+	}
+
+	// the next crawl reads the sitemaps again for new recipes, but doesn't refetch anything
+	entries = crawl(t, c)
+	fetched = site.fetched()
+	if len(entries) != 0 || fetched["/post-sitemap.xml"] != 1 || fetched["/about/"] != 0 || fetched["/beef-stew/"] != 0 {
+		t.Errorf("second crawl got %v and fetched %v, want only the sitemaps", entries, fetched)
+	}
+
+	c.RetryFailed = true
+	entries = crawl(t, c)
+	if fetched = site.fetched(); len(entries) != 1 || fetched["/about/"] != 1 {
+		t.Errorf("retrying got %v and fetched %v, want only /about/", entries, fetched)
+	}
+}
+
+func TestCrawlResume(t *testing.T) {
+	site := newTestSite(t, false)
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Crawler{Site: site.URL, Store: s, Checkpoint: filepath.Join(t.TempDir(), "checkpoint.json"), Limit: 1}
+
+	if entries := crawl(t, c); len(entries) != 1 || entries["/lentil-soup/"].Status != batch.OK {
+		t.Errorf("first crawl got %v, want only lentil soup", entries)
+	}
+	cp, err := loadCheckpoint(c.Checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Complete || len(cp.Found) != 4 || cp.Tried[site.URL+"/lentil-soup/"] != batch.OK {
+		t.Errorf("checkpoint = %+v, want 4 found and lentil soup tried", cp)
+	}
+	site.fetched()
+
+	// the rest of the recipes are fetched without reading the sitemaps again
+	c = &Crawler{Site: site.URL, Store: s, Checkpoint: c.Checkpoint}
+	entries := crawl(t, c)
+	fetched := site.fetched()
+	if len(entries) != 3 || entries["/beef-stew/"].Status != batch.OK || entries["/about/"].Status != batch.Failed {
+		t.Errorf("resumed crawl got %v, want the other 3 pages", entries)
+	}
+	if fetched["/sitemap_index.xml"] != 0 || fetched["/lentil-soup/"] != 0 {
+		t.Errorf("resumed crawl fetched %v, want no sitemaps", fetched)
+	}
+	if cp, _ = loadCheckpoint(c.Checkpoint); !cp.Complete {
+		t.Error("checkpoint should be complete")
+	}
+}
+
+// Revalidating a cached page is still a request, so the delay has to be kept between them too.
+func TestCrawlDelayCached(t *testing.T) {
+	site := newTestSite(t, false)
+	c := &Crawler{Site: site.URL, Fetcher: &fetch.Fetcher{Cache: &fetch.Cache{Dir: t.TempDir()}}, Delay: 50 * time.Millisecond}
+	// the second crawl saves to an empty store, so that every page is fetched again from the cache
+	for i := 0; i < 2; i++ {
+		s, err := store.NewDir(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Store = s
+		crawl(t, c)
+	}
+
+	site.mu.Lock()
+	defer site.mu.Unlock()
+	if site.notModified == 0 {
+		t.Fatal("no pages were revalidated")
+	}
+	for i := 1; i < len(site.times); i++ {
+		if gap := site.times[i].Sub(site.times[i-1]); gap < 50*time.Millisecond {
+			t.Errorf("request %d (%s) came %v after the one before it, want at least 50ms", i, site.requests[i], gap)
+		}
+	}
+}
+
+func TestCrawlCategories(t *testing.T) {
+	site := newTestSite(t, true)
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Crawler{Site: site.URL, Store: s}
+	if _, err := c.Discover(context.Background()); err == nil {
+		t.Error("Discover without sitemaps or categories should fail")
+	}
+
+	c = &Crawler{Site: site.URL, Store: s, Categories: []string{"/category/recipes/"}}
+	found, err := c.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// disallowed pages are found, but skipped by Run
+	want := []string{"/about/", "/wp-admin/", "/lentil-soup/", "/members-only-pie/", "/beef-stew/"}
+	for i := range found {
+		found[i] = strings.TrimPrefix(found[i], site.URL)
+	}
+	if strings.Join(found, " ") != strings.Join(want, " ") {
+		t.Errorf("found %v, want %v", found, want)
+	}
+	if fetched := site.fetched(); fetched["/category/recipes/page/2/"] != 1 || fetched["/category/recipes/"] != 1 || fetched["/wp-admin/"] != 0 {
+		t.Errorf("fetched %v, want both category pages once", fetched)
+	}
+}
@@ -0,0 +1,151 @@
+package crawl
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots holds the rules of a robots.txt file that apply to one user agent.
+// See https://www.rfc-editor.org/rfc/rfc9309.
+type Robots struct {
+	rules []rule
+	// Delay is the Crawl-delay asked for by the site, if any.
+	Delay time.Duration
+	// Sitemaps lists the sitemaps named by the file. These apply to every user agent.
+	Sitemaps []string
+}
+
+type rule struct {
+	allow bool
+	path  string
+}
+
+// ParseRobots reads a robots.txt file, keeping the group of rules for the agent (matched by the
+// agent's product name, eg. "recipe-parser" for "recipe-parser/1.0") or the "*" group if the agent
+// isn't named.
+func ParseRobots(r io.Reader, agent string) (*Robots, error) {
+	agent, _, _ = strings.Cut(strings.ToLower(agent), "/")
+
+	// Each group starts with one or more User-agent lines. mine and star track whether the current
+	// group names the agent or "*", and inAgents whether we're still reading its User-agent lines.
+	var (
+		robots               = &Robots{}
+		mineRules, starRules []rule
+		mineDelay, starDelay time.Duration
+		mine, star, inAgents bool
+		foundMine            bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				mine, star, inAgents = false, false, true
+			}
+			name := strings.ToLower(value)
+			if name == "*" {
+				star = true
+			} else if agent != "" && strings.Contains(agent, name) {
+				mine, foundMine = true, true
+			}
+			continue
+		case "sitemap":
+			robots.Sitemaps = append(robots.Sitemaps, value)
+			continue
+		}
+
+		inAgents = false
+		switch key {
+		case "allow", "disallow":
+			// an empty Disallow allows everything, so it adds no rule
+			if value == "" {
+				continue
+			}
+			r := rule{allow: key == "allow", path: value}
+			if mine {
+				mineRules = append(mineRules, r)
+			}
+			if star {
+				starRules = append(starRules, r)
+			}
+		case "crawl-delay":
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			d := time.Duration(seconds * float64(time.Second))
+			if mine {
+				mineDelay = d
+			}
+			if star {
+				starDelay = d
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if foundMine {
+		robots.rules, robots.Delay = mineRules, mineDelay
+	} else {
+		robots.rules, robots.Delay = starRules, starDelay
+	}
+	return robots, nil
+}
+
+// Allowed reports whether the path (which may include a query) can be crawled. The longest
+// matching rule wins, and Allow wins a tie. Paths that no rule matches are allowed.
+func (r *Robots) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !matchRule(rule.path, path) {
+			continue
+		}
+		if n := len(rule.path); n > longest || n == longest && rule.allow {
+			allowed, longest = rule.allow, n
+		}
+	}
+	return allowed
+}
+
+// matchRule matches a rule's path against a URL path. "*" matches any run of characters and a
+// trailing "$" anchors the rule to the end of the path. Otherwise, rules match by prefix.
+func matchRule(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			// the last part has to end the path
+			return strings.HasSuffix(path, part)
+		}
+		at := strings.Index(path, part)
+		if at < 0 {
+			return false
+		}
+		path = path[at+len(part):]
+	}
+	return !anchored || path == ""
+}
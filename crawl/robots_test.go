@@ -0,0 +1,70 @@
+package crawl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/fetch"
+)
+
+func TestParseRobots(t *testing.T) {
+	file := `User-agent: *
+Disallow: /wp-admin/
+Allow: /wp-admin/admin-ajax.php
+Disallow: /*?s=
+Disallow: /*.pdf$
+Crawl-delay: 5
+
+# recipe-parser gets its own rules
+User-agent: Googlebot
+User-agent: recipe-parser
+Disallow: /private/
+Disallow:
+Crawl-delay: 1.5
+
+Sitemap: https://www.example.com/sitemap_index.xml
+`
+	r, err := ParseRobots(strings.NewReader(file), "Other/2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Delay != 5*time.Second {
+		t.Errorf("Delay = %v, want 5s", r.Delay)
+	}
+	if len(r.Sitemaps) != 1 || r.Sitemaps[0] != "https://www.example.com/sitemap_index.xml" {
+		t.Errorf("Sitemaps = %v", r.Sitemaps)
+	}
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/lentil-soup/", true},
+		{"/wp-admin/", false},
+		{"/wp-admin/options.php", false},
+		{"/wp-admin/admin-ajax.php", true},
+		{"/?s=soup", false},
+		{"/search/?s=soup", false},
+		{"/guide.pdf", false},
+		{"/guide.pdf?download=1", true},
+		{"/private/soup/", true},
+	}
+	for _, test := range tests {
+		if got := r.Allowed(test.path); got != test.allowed {
+			t.Errorf("Allowed(%q) = %v, want %v", test.path, got, test.allowed)
+		}
+	}
+
+	// a group naming the agent replaces the "*" group
+	r, err = ParseRobots(strings.NewReader(file), fetch.DefaultUserAgent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Delay != 1500*time.Millisecond {
+		t.Errorf("Delay = %v, want 1.5s", r.Delay)
+	}
+	if r.Allowed("/private/soup/") || !r.Allowed("/wp-admin/") {
+		t.Error("recipe-parser should only follow its own group")
+	}
+}
@@ -0,0 +1,55 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// sitemap is either a sitemap index (which lists other sitemaps) or a list of pages (a urlset).
+// See https://www.sitemaps.org/protocol.html.
+type sitemap struct {
+	XMLName  xml.Name
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// parseSitemap reads a sitemap, which may be gzipped, and returns the sitemaps it lists (for an
+// index) and the pages it lists (for a urlset).
+func parseSitemap(r io.Reader) (sitemaps, pages []string, err error) {
+	br := bufio.NewReader(r)
+	// gzip files start with 1f 8b
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	var sm sitemap
+	if err = xml.NewDecoder(r).Decode(&sm); err != nil {
+		return nil, nil, err
+	}
+	for _, s := range sm.Sitemaps {
+		if loc := strings.TrimSpace(s.Loc); loc != "" {
+			sitemaps = append(sitemaps, loc)
+		}
+	}
+	for _, u := range sm.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			pages = append(pages, loc)
+		}
+	}
+	return sitemaps, pages, nil
+}
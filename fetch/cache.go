@@ -0,0 +1,101 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps downloaded pages in a directory. Each page is saved as two files named by the hash
+// of its URL: <hash>.json holds the URL, status, headers and fetch time, and <hash>.html holds the body.
+type Cache struct {
+	Dir string
+}
+
+// cacheEntry is the contents of a <hash>.json file.
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header"`
+	FetchedAt  time.Time   `json:"fetchedAt"`
+}
+
+func (c *Cache) key(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the cached page, or nil if the page isn't cached.
+func (c *Cache) Get(source string) (*Response, error) {
+	key := c.key(source)
+	data, err := os.ReadFile(key + ".json")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil || entry.URL != source {
+		// a broken or clashing entry is fetched again
+		return nil, nil
+	}
+	body, err := os.ReadFile(key + ".html")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.Header == nil {
+		entry.Header = http.Header{}
+	}
+	return &Response{
+		URL:        entry.URL,
+		StatusCode: entry.StatusCode,
+		Header:     entry.Header,
+		Body:       body,
+		FetchedAt:  entry.FetchedAt,
+		FromCache:  true,
+	}, nil
+}
+
+// Put saves the page. The body is written before its metadata, so an interrupted Put leaves at
+// worst a new body with the old validators, which only means the page is downloaded again.
+func (c *Cache) Put(resp *Response) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	key := c.key(resp.URL)
+	data, err := json.MarshalIndent(cacheEntry{resp.URL, resp.StatusCode, resp.Header, resp.FetchedAt}, "", "\t")
+	if err != nil {
+		return err
+	}
+	if !resp.FromCache {
+		if err = writeFile(key+".html", resp.Body); err != nil {
+			return err
+		}
+	}
+	return writeFile(key+".json", data)
+}
+
+// writeFile replaces the file in one step by renaming a temporary file over it.
+func writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,265 @@
+// Package fetch downloads web pages for the parser. Requests time out, identify themselves with a
+// User-Agent, and are retried with backoff when the site is busy or down. Pages can be kept in an
+// on-disk cache, which is revalidated with conditional requests (ETag and Last-Modified) so that
+// unchanged pages aren't downloaded again, or used on its own to work offline.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultUserAgent identifies the parser to websites.
+	DefaultUserAgent = "recipe-parser/1.0 (+https://github.com/ejacobg/recipe-parser)"
+	DefaultTimeout   = 30 * time.Second
+	DefaultRetries   = 3
+	DefaultBackoff   = time.Second
+	// maxBackoff caps the wait between retries, including waits asked for by Retry-After.
+	maxBackoff = time.Minute
+	// maxBodySize limits the size of a page, in case a URL points at something that isn't one.
+	maxBodySize = 16 << 20
+)
+
+// ErrNotCached is returned in offline mode for pages that aren't in the cache.
+var ErrNotCached = errors.New("page isn't cached, and fetching is offline")
+
+// StatusError is returned for a response that isn't 200 OK (once any retries have run out).
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "non-OK HTTP status from " + e.URL + ": " + e.Status
+}
+
+// permanentError is an error from once that trying the request again won't fix, eg. a bad URL.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Response is a downloaded (or cached) page.
+type Response struct {
+	// URL is the URL that was requested.
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// FetchedAt is when the page was downloaded, or last revalidated if it came from the cache.
+	FetchedAt time.Time
+	// FromCache is set if the body was read from the cache rather than downloaded.
+	FromCache bool
+	// Revalidated is set if a request was still made for a page from the cache, which answered
+	// 304 Not Modified.
+	Revalidated bool
+}
+
+// Fetcher makes GET requests. The zero value is usable, and makes requests with the defaults
+// above and no cache. A Fetcher may be used by several goroutines at once, but its fields
+// shouldn't be changed while it is in use.
+type Fetcher struct {
+	// Client makes the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Timeout limits each attempt at a request, including reading the body. Defaults to DefaultTimeout.
+	Timeout   time.Duration
+	UserAgent string
+	// Retries is how many times a failed request is tried again. Network errors, 5xx statuses and
+	// 429 Too Many Requests are retried. Negative means never retry; zero means DefaultRetries.
+	Retries int
+	// Backoff is the wait before the first retry, which doubles with every retry after it, unless
+	// the response says how long to wait with Retry-After. Defaults to DefaultBackoff.
+	Backoff time.Duration
+	// Cache keeps downloaded pages, if set.
+	Cache *Cache
+	// MaxAge is how long a cached page is used without checking whether it has changed.
+	// Zero means cached pages are always revalidated.
+	MaxAge time.Duration
+	// Offline only reads pages from the cache, returning ErrNotCached for any that aren't.
+	Offline bool
+
+	// sleep waits between retries. Tests replace it to avoid waiting.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Default is the fetcher used by sites.Fetch unless the program sets up its own.
+var Default = &Fetcher{}
+
+// Get downloads the page at source, or reads it from the cache.
+func (f *Fetcher) Get(ctx context.Context, source string) (*Response, error) {
+	var cached *Response
+	if f.Cache != nil {
+		var err error
+		if cached, err = f.Cache.Get(source); err != nil {
+			return nil, err
+		}
+	}
+	if f.Offline {
+		if cached == nil {
+			return nil, ErrNotCached
+		}
+		return cached, nil
+	}
+	if cached != nil && f.MaxAge > 0 && time.Since(cached.FetchedAt) < f.MaxAge {
+		return cached, nil
+	}
+
+	resp, err := f.do(ctx, source, cached)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cached.FetchedAt = resp.FetchedAt
+		cached.Revalidated = true
+		// keep any updated validators
+		for _, key := range []string{"ETag", "Last-Modified"} {
+			if value := resp.Header.Get(key); value != "" {
+				cached.Header.Set(key, value)
+			}
+		}
+		resp = cached
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: source, StatusCode: resp.StatusCode, Status: strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)}
+	}
+	if f.Cache != nil {
+		if err = f.Cache.Put(resp); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// do makes the request, retrying it as needed. Conditional headers are added from the cached page.
+func (f *Fetcher) do(ctx context.Context, source string, cached *Response) (*Response, error) {
+	retries := f.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	backoff := f.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	sleep := f.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := f.once(ctx, source, cached)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return nil, permanent.err
+		}
+		retry := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		if !retry || attempt >= retries {
+			return resp, err
+		}
+
+		wait := backoff << attempt
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = after
+			}
+		}
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+		if err = sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// once makes a single attempt at the request.
+func (f *Fetcher) once(ctx context.Context, source string, cached *Response) (*Response, error) {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+	if err != nil {
+		return nil, &permanentError{err}
+	}
+	userAgent := f.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if cached != nil {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBodySize {
+		return nil, &permanentError{fmt.Errorf("%s is larger than %d MB", source, maxBodySize>>20)}
+	}
+	return &Response{
+		URL:        source,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		FetchedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// retryAfter reads a Retry-After header, which is either a number of seconds or a date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
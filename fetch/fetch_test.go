@@ -0,0 +1,206 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer counts requests, and answers with the handler for the attempt (the last handler is
+// reused once they run out).
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	handlers []http.HandlerFunc
+}
+
+func newTestServer(t *testing.T, handlers ...http.HandlerFunc) *testServer {
+	s := &testServer{handlers: handlers}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		h := s.handlers[len(s.handlers)-1]
+		if len(s.requests) <= len(s.handlers) {
+			h = s.handlers[len(s.requests)-1]
+		}
+		s.mu.Unlock()
+		h(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func status(code int, header ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+// page serves a page with an ETag, answering 304 Not Modified to requests that already have it.
+func page(body, etag string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(body))
+	}
+}
+
+// noSleep records the waits between retries instead of sleeping.
+func noSleep(f *Fetcher) *[]time.Duration {
+	var waits []time.Duration
+	f.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return &waits
+}
+
+func TestGetRetries(t *testing.T) {
+	s := newTestServer(t,
+		status(http.StatusServiceUnavailable),
+		status(http.StatusTooManyRequests, "Retry-After", "7"),
+		page("<html>soup</html>", `"v1"`),
+	)
+	f := &Fetcher{Backoff: time.Second}
+	waits := noSleep(f)
+
+	resp, err := f.Get(context.Background(), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != "<html>soup</html>" || resp.FromCache {
+		t.Errorf("got %+v", resp)
+	}
+	if len(*waits) != 2 || (*waits)[0] != time.Second || (*waits)[1] != 7*time.Second {
+		t.Errorf("waited %v, want [1s 7s]", *waits)
+	}
+	if ua := s.requests[0].UserAgent(); ua != DefaultUserAgent {
+		t.Errorf("User-Agent = %q", ua)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	// 404 isn't retried
+	s := newTestServer(t, status(http.StatusNotFound))
+	f := &Fetcher{UserAgent: "test/1.0"}
+	waits := noSleep(f)
+	_, err := f.Get(context.Background(), s.URL)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("got %v, want a 404 StatusError", err)
+	}
+	if len(s.requests) != 1 || len(*waits) != 0 || s.requests[0].UserAgent() != "test/1.0" {
+		t.Errorf("made %d requests, want 1", len(s.requests))
+	}
+
+	// 500 is retried until the retries run out, backing off each time
+	s = newTestServer(t, status(http.StatusInternalServerError))
+	f = &Fetcher{Retries: 2, Backoff: time.Second}
+	waits = noSleep(f)
+	if _, err = f.Get(context.Background(), s.URL); !errors.As(err, &statusErr) || statusErr.StatusCode != 500 {
+		t.Errorf("got %v, want a 500 StatusError", err)
+	}
+	if len(s.requests) != 3 || len(*waits) != 2 || (*waits)[1] != 2*time.Second {
+		t.Errorf("made %d requests and waited %v, want 3 requests after 1s and 2s", len(s.requests), *waits)
+	}
+
+	// neither are bad URLs or pages that are too large
+	f = &Fetcher{}
+	waits = noSleep(f)
+	if _, err = f.Get(context.Background(), "http://[::1"); err == nil || len(*waits) != 0 {
+		t.Errorf("got %v after waiting %v, want an error without retries", err, *waits)
+	}
+	s = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, maxBodySize+1))
+	})
+	if _, err = f.Get(context.Background(), s.URL); err == nil || len(s.requests) != 1 || len(*waits) != 0 {
+		t.Errorf("got %v after %d requests, want an error without retries", err, len(s.requests))
+	}
+
+	// slow pages time out
+	s = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	f = &Fetcher{Timeout: 20 * time.Millisecond, Retries: -1}
+	if _, err = f.Get(context.Background(), s.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want a timeout", err)
+	}
+}
+
+func TestGetCache(t *testing.T) {
+	s := newTestServer(t, page("<html>soup</html>", `"v1"`))
+	f := &Fetcher{Cache: &Cache{Dir: t.TempDir()}}
+
+	resp, err := f.Get(context.Background(), s.URL)
+	if err != nil || resp.FromCache {
+		t.Fatalf("got %+v, %v, want a download", resp, err)
+	}
+	first := resp.FetchedAt
+
+	// the cached page is revalidated, and used since it hasn't changed
+	resp, err = f.Get(context.Background(), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.FromCache || !resp.Revalidated || string(resp.Body) != "<html>soup</html>" || resp.FetchedAt.Before(first) {
+		t.Errorf("got %+v, want the cached page", resp)
+	}
+	if len(s.requests) != 2 || s.requests[1].Header.Get("If-None-Match") != `"v1"` {
+		t.Errorf("second request = %v, want a conditional request", s.requests[1].Header)
+	}
+
+	// a changed page replaces the cached one
+	s.handlers = []http.HandlerFunc{page("<html>stew</html>", `"v2"`)}
+	if resp, err = f.Get(context.Background(), s.URL); err != nil || string(resp.Body) != "<html>stew</html>" {
+		t.Fatalf("got %+v, %v, want the new page", resp, err)
+	}
+
+	// MaxAge and Offline don't make requests
+	f.MaxAge = time.Hour
+	if resp, err = f.Get(context.Background(), s.URL); err != nil || !resp.FromCache || resp.Revalidated {
+		t.Errorf("got %+v, %v, want the cached page", resp, err)
+	}
+	f = &Fetcher{Cache: f.Cache, Offline: true}
+	if resp, err = f.Get(context.Background(), s.URL); err != nil || string(resp.Body) != "<html>stew</html>" {
+		t.Errorf("offline got %+v, %v, want the cached page", resp, err)
+	}
+	if _, err = f.Get(context.Background(), s.URL+"/other/"); err != ErrNotCached {
+		t.Errorf("offline got %v, want ErrNotCached", err)
+	}
+	if len(s.requests) != 3 {
+		t.Errorf("made %d requests, want 3", len(s.requests))
+	}
+}
+
+func TestGetLastModified(t *testing.T) {
+	modified := "Mon, 24 Oct 2022 12:00:00 GMT"
+	s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", modified)
+		if r.Header.Get("If-Modified-Since") == modified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("<html>soup</html>"))
+	})
+	f := &Fetcher{Cache: &Cache{Dir: t.TempDir()}}
+	for i := 0; i < 2; i++ {
+		resp, err := f.Get(context.Background(), s.URL)
+		if err != nil || string(resp.Body) != "<html>soup</html>" || resp.FromCache != (i == 1) {
+			t.Errorf("request %d got %+v, %v", i, resp, err)
+		}
+	}
+}
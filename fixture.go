@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/ejacobg/recipe-parser/fetch"
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/sites"
+)
+
+func init() {
+	commands["fixture"] = command{
+		usage: "recipe-parser fixture [-dir recipe/test-data] [-name name] [-force] <recipe-name|recipe-url>...",
+		run:   runFixture,
+	}
+}
+
+// defaultFixtureDir is the parser's test data, see TestFixtures in the recipe package.
+const defaultFixtureDir = "./recipe/test-data/"
+
+// runFixture downloads recipe pages into the parser's test data (responses/<name>.html), along with
+// what the parser currently extracts from them (solutions/<name>.json). The solutions are only as
+// good as the parser, so check them by hand before committing them.
+func runFixture(args []string) error {
+	fs := flag.NewFlagSet("fixture", flag.ExitOnError)
+	dir := fs.String("dir", defaultFixtureDir, "test data directory, holding the responses and solutions directories")
+	name := fs.String("name", "", "name of the fixture if one recipe is given (defaults to the last part of its URL)")
+	force := fs.Bool("force", false, "overwrite fixtures that already exist")
+	setupFetch := fetchFlags(fs)
+	fs.Parse(args)
+	if err := setupFetch(); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || (*name != "" && fs.NArg() > 1) {
+		return errors.New("usage: recipe-parser fixture [flags] <recipe>... (-name needs a single recipe)")
+	}
+
+	for _, arg := range fs.Args() {
+		source := sites.Canonicalize(arg)
+		fixture := *name
+		if fixture == "" {
+			fixture = fixtureName(source)
+		}
+		if err := captureFixture(context.TODO(), *dir, fixture, source, *force); err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		log.Println("Saved fixture", fixture)
+	}
+	return nil
+}
+
+// fixtureName names a fixture after the last part of its URL's path, which is the recipe name for
+// budgetbytes.com.
+func fixtureName(source string) string {
+	u, err := url.Parse(source)
+	if err != nil {
+		return source
+	}
+	if name := path.Base(strings.TrimSuffix(u.Path, "/")); name != "." && name != "/" {
+		return name
+	}
+	return u.Hostname()
+}
+
+// captureFixture saves the page at source and its solution under dir.
+func captureFixture(ctx context.Context, dir, name, source string, force bool) error {
+	response := filepath.Join(dir, "responses", name+".html")
+	solution := filepath.Join(dir, "solutions", name+".json")
+	if _, err := os.Stat(response); err == nil && !force {
+		return errors.New(response + " already exists, use -force to overwrite it")
+	}
+
+	page, err := fetch.Default.Get(ctx, source)
+	if err != nil {
+		return err
+	}
+	// The tests parse the whole page, so the solution has to come from it too.
+	doc, err := html.Parse(bytes.NewReader(page.Body))
+	if err != nil {
+		return err
+	}
+	res, err := recipe.FromHTML(doc)
+	if err != nil {
+		return err
+	}
+	for _, w := range res.Warnings {
+		log.Println("Warning:", name+":", w)
+	}
+
+	for _, sub := range []string{"responses", "solutions"} {
+		if err = os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return err
+		}
+	}
+	if err = os.WriteFile(response, page.Body, 0644); err != nil {
+		return err
+	}
+	return writeSolution(solution, res.Recipe)
+}
+
+// writeSolution writes the recipe in the same format as the golden files in recipe/test-data.
+func writeSolution(name string, rcp *models.Recipe) error {
+	data, err := json.MarshalIndent(rcp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0644)
+}
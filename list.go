@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+func init() {
+	commands["list"] = command{
+		usage: "recipe-parser list [-dbpath dir]",
+		run:   list,
+	}
+}
+
+// list prints the ID, name and URL of every saved recipe.
+func list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	dbPath := dbFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return errors.New("list takes no arguments")
+	}
+
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close(db)
+	recipes, err := db.List(context.TODO())
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, rcp := range recipes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", rcp.ID, rcp.Name, rcp.URL)
+	}
+	return w.Flush()
+}
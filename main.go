@@ -5,38 +5,59 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"path/filepath"
 
 	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/sites"
+	"github.com/ejacobg/recipe-parser/store"
+	"github.com/ejacobg/recipe-parser/units"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-
-	"golang.org/x/net/html"
 )
 
 func init() {
 	flag.Usage = func() {
 		w := flag.CommandLine.Output()
 		fmt.Fprintln(w, "Usage of recipe-parser:")
-		fmt.Fprintln(w, "recipe-parser <recipe-name>")
+		fmt.Fprintln(w, "recipe-parser <recipe-name|recipe-url>")
 		fmt.Fprintln(w, "recipe-parser -json <recipe-name>.json")
-		fmt.Fprintln(w, "Obtain the recipe name from the budgetbytes.com URL.")
+		for _, usage := range commandUsages() {
+			fmt.Fprintln(w, usage)
+		}
+		fmt.Fprintln(w, "Obtain the recipe name from the budgetbytes.com URL, or pass the full URL of a recipe.")
 		flag.PrintDefaults()
 	}
 }
 
 var (
 	readJSON = flag.Bool("json", false, "constructs a Recipe from a JSON file, then prints it")
-	dbPath   = flag.String("dbpath", "./database/", "path to save JSON output")
+	dbPath   = flag.String("dbpath", defaultDBPath, dbUsage)
+	strict   = flag.Bool("strict", false, "fail if any field of the recipe can't be parsed")
+	markdown = flag.Bool("markdown", false, "keep the links, bold and italics in the instructions and summary as Markdown")
+	unitsTo  = flag.String("units", "", "print the recipe with its ingredient amounts in \"metric\" or \"us\" units (it is saved as parsed)")
+
+	setupFetch = fetchFlags(flag.CommandLine)
 )
 
 func main() {
 	log.SetFlags(0)
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				log.Fatalln("Error:", err)
+			}
+			return
+		}
+	}
+
 	flag.Parse()
 	args := flag.Args()
+	if err := setupFetch(); err != nil {
+		log.Fatalln("Error:", err)
+	}
 
 	mongodb()
 
@@ -49,35 +70,58 @@ func main() {
 		if err != nil {
 			log.Fatalln("Error:", err)
 		}
+		if err = convertUnits(r, *unitsTo); err != nil {
+			log.Fatalln("Error:", err)
+		}
 		fmt.Println(r)
 		os.Exit(0)
 	}
 
-	baseURL := "http://www.budgetbytes.com/"
-	resp, err := http.Get(baseURL + args[0])
+	if *unitsTo != "" {
+		if _, err := units.ParseSystem(*unitsTo); err != nil {
+			log.Fatalln("Error:", err)
+		}
+	}
+	source := sites.Canonicalize(args[0])
+	var opts []recipe.Option
+	if *strict {
+		opts = append(opts, recipe.Strict())
+	}
+	if *markdown {
+		opts = append(opts, recipe.Markdown())
+	}
+	res, err := sites.Fetch(source, opts...)
 	if err != nil {
 		log.Fatalln("Error:", err)
 	}
-	defer resp.Body.Close()
-
-	fmt.Println(resp.Status)
+	fmt.Println("Parsed using", res.Strategy)
+	for _, w := range res.Warnings {
+		log.Println("Warning:", w)
+	}
 
-	doc, err := html.Parse(resp.Body)
+	db, err := store.Open(*dbPath)
 	if err != nil {
 		log.Fatalln("Error:", err)
 	}
-
-	r, err := recipe.FromHTML(doc)
-	if err != nil {
+	defer store.Close(db)
+	// Parsing a recipe again updates the saved copy, along with the snapshot of its page.
+	if err = store.Save(context.TODO(), db, res.Recipe, res.Snapshot); err != nil {
+		store.Close(db)
 		log.Fatalln("Error:", err)
 	}
 
-	err = r.SaveAs(*dbPath + os.Args[1])
-	if err != nil {
-		log.Fatalln("Error:", err)
+	saved := *dbPath
+	if _, ok := db.(*store.Dir); ok {
+		saved = filepath.Join(*dbPath, store.FileName(res.Recipe))
 	}
+	fmt.Println("Recipe saved to " + saved)
 
-	fmt.Println("Recipe saved to " + *dbPath + args[0])
+	// Only the printed copy is converted, so that the saved recipe matches its page.
+	if *unitsTo != "" {
+		if err = printConverted(res.Recipe, *unitsTo); err != nil {
+			log.Fatalln("Error:", err)
+		}
+	}
 }
 
 func mongodb() {
@@ -108,7 +152,7 @@ func mongodb() {
 		log.Println("Could not read recipe from file")
 	}
 
-	filter := bson.D{{"id", r.ID}}
+	filter := bson.D{{Key: "id", Value: r.ID}}
 	opts := options.Replace().SetUpsert(true)
 	result, err := coll.ReplaceOne(context.TODO(), filter, r, opts)
 	if err != nil {
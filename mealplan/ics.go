@@ -0,0 +1,118 @@
+package mealplan
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ejacobg/recipe-parser/models"
+)
+
+// WriteICS writes the planned meals as an iCalendar (.ics) file, with an event for cooking each
+// meal that lasts as long as the recipe's prep and cook times (see Planned.Times). Times are
+// written without a time zone, so calendars show them in the local time of the viewer.
+// now is used as the time the events were created.
+// See https://www.rfc-editor.org/rfc/rfc5545.
+func WriteICS(w io.Writer, plan *models.Plan, planned []Planned, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	name := plan.Name
+	if name == "" {
+		name = plan.ID
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//ejacobg//recipe-parser//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", escapeText(name))
+	for _, p := range planned {
+		start, end, err := p.Times()
+		if err != nil {
+			return err
+		}
+		line("BEGIN", "VEVENT")
+		line("UID", p.Date+"-"+string(p.Slot)+"-"+plan.ID+"@recipe-parser")
+		line("DTSTAMP", now.UTC().Format("20060102T150405Z"))
+		line("DTSTART", start.Format("20060102T150405"))
+		line("DTEND", end.Format("20060102T150405"))
+		line("SUMMARY", escapeText(strings.ToUpper(string(p.Slot[:1]))+string(p.Slot[1:])+": "+p.Recipe.Name))
+		if description := describe(p); description != "" {
+			line("DESCRIPTION", escapeText(description))
+		}
+		if p.Recipe.URL != "" {
+			line("URL", p.Recipe.URL)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// describe lists the details of the meal for its event, eg. the servings and times.
+func describe(p Planned) string {
+	var lines []string
+	if p.Recipe.Servings > 0 {
+		lines = append(lines, "Servings: "+strconv.FormatFloat(p.Recipe.Servings, 'f', -1, 64))
+	}
+	for _, t := range []struct {
+		name string
+		d    models.Duration
+	}{
+		{"Prep time", p.Recipe.PrepTime},
+		{"Cook time", p.Recipe.CookTime},
+		{"Total time", p.Recipe.TotalTime},
+	} {
+		if t.d > 0 {
+			lines = append(lines, t.name+": "+formatMinutes(t.d.Minutes()))
+		}
+	}
+	if p.Recipe.URL != "" {
+		lines = append(lines, p.Recipe.URL)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatMinutes writes a time the way recipe cards do, eg. "1 hr 15 mins".
+func formatMinutes(minutes int64) string {
+	var parts []string
+	if hours := minutes / 60; hours == 1 {
+		parts = append(parts, "1 hr")
+	} else if hours > 1 {
+		parts = append(parts, strconv.FormatInt(hours, 10)+" hrs")
+	}
+	if minutes %= 60; minutes == 1 {
+		parts = append(parts, "1 min")
+	} else if minutes > 1 || len(parts) == 0 {
+		parts = append(parts, strconv.FormatInt(minutes, 10)+" mins")
+	}
+	return strings.Join(parts, " ")
+}
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded writes a content line, folding it onto continuation lines so that no line is longer
+// than 75 octets. Lines end with CRLF.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		// don't split a multi-byte character
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// the leading space counts towards the continuation line's length
+		limit = 74
+	}
+	w.WriteString(s + "\r\n")
+}
@@ -0,0 +1,134 @@
+// Package mealplan works with the recipes of a meal plan (see models.Plan): the shopping list for
+// a range of days, the total cost, and the calendar of when to cook.
+package mealplan
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/shopping"
+	"github.com/ejacobg/recipe-parser/store"
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+// Planned is a meal along with its recipe, scaled to the planned number of servings.
+type Planned struct {
+	models.Meal
+	Recipe *models.Recipe
+}
+
+// Load looks up the recipe of every meal. Each recipe is only read once, but every meal gets its
+// own copy to scale.
+func Load(ctx context.Context, s store.RecipeStore, meals []models.Meal) ([]Planned, error) {
+	recipes := make(map[string]*models.Recipe)
+	var planned []Planned
+	for _, m := range meals {
+		rcp, ok := recipes[m.RecipeID]
+		if !ok {
+			var err error
+			rcp, err = s.GetByID(ctx, m.RecipeID)
+			if err == store.ErrNotFound {
+				return nil, errors.New(m.Date + " " + string(m.Slot) + ": recipe " + m.RecipeID + " not found")
+			}
+			if err != nil {
+				return nil, err
+			}
+			recipes[m.RecipeID] = rcp
+		}
+		rcp = copyRecipe(rcp)
+		if m.Servings > 0 {
+			if err := rcp.ScaleToServings(m.Servings); err != nil {
+				return nil, errors.New(m.Date + " " + string(m.Slot) + ": " + rcp.Name + ": " + err.Error())
+			}
+		}
+		planned = append(planned, Planned{m, rcp})
+	}
+	return planned, nil
+}
+
+// copyRecipe makes a copy of the recipe that can be scaled without changing the original.
+func copyRecipe(rcp *models.Recipe) *models.Recipe {
+	c := *rcp
+	c.Ingredients = make(models.IngredientGroups, len(rcp.Ingredients))
+	for g, group := range rcp.Ingredients {
+		c.Ingredients[g] = models.IngredientGroup{Name: group.Name, Ingredients: make([]models.Ingredient, len(group.Ingredients))}
+		for i, ingredient := range group.Ingredients {
+			if ingredient.Quantity != nil {
+				q := *ingredient.Quantity
+				ingredient.Quantity = &q
+			}
+			c.Ingredients[g].Ingredients[i] = ingredient
+		}
+	}
+	if rcp.Cost != nil {
+		cost := *rcp.Cost
+		c.Cost = &cost
+	}
+	return &c
+}
+
+// ShoppingList combines the ingredients of every planned meal.
+func ShoppingList(planned []Planned, system units.System) *shopping.List {
+	recipes := make([]*models.Recipe, len(planned))
+	for i, p := range planned {
+		recipes[i] = p.Recipe
+	}
+	return shopping.New(recipes, system)
+}
+
+// Cost is the total cost of a plan.
+type Cost struct {
+	// Total is the cost of every priced meal. It is nil if no meal has a price, or if the prices
+	// use different currencies.
+	Total *models.Money `json:"total,omitempty"`
+	// Unpriced lists the meals without a price, eg. "2022-10-24 lunch".
+	Unpriced []string `json:"unpriced"`
+}
+
+// TotalCost adds up the cost of the planned meals. A meal costs the total of its (scaled)
+// ingredient prices, or the recipe's stated cost if its ingredients have no prices.
+func TotalCost(planned []Planned) Cost {
+	c := Cost{Unpriced: []string{}}
+	mixed := false
+	for _, p := range planned {
+		cost, ok := p.Recipe.IngredientsCost()
+		if !ok && p.Recipe.Cost != nil && p.Recipe.Cost.Recipe != nil {
+			cost, ok = *p.Recipe.Cost.Recipe, true
+		}
+		switch {
+		case !ok:
+			c.Unpriced = append(c.Unpriced, p.Date+" "+string(p.Slot))
+		case c.Total == nil:
+			c.Total = &models.Money{Cents: cost.Cents, Currency: cost.Currency}
+		case c.Total.Currency != cost.Currency:
+			mixed = true
+		default:
+			c.Total.Cents += cost.Cents
+		}
+	}
+	if mixed {
+		c.Total = nil
+	}
+	return c
+}
+
+// DefaultCookingTime is how long a meal is assumed to take if its recipe doesn't say.
+const DefaultCookingTime = 30 * time.Minute
+
+// Times returns when to start cooking the meal and when it is ready, as a time of day on its date.
+// The meal is ready at its slot's usual time (see models.Slot.Time), and cooking starts early
+// enough to fit the recipe's prep and cook times.
+func (p Planned) Times() (start, end time.Time, err error) {
+	day, err := p.Day()
+	if err != nil {
+		return start, end, err
+	}
+	end = day.Add(p.Slot.Time())
+	needed := time.Duration(p.Recipe.TimeNeeded())
+	if needed <= 0 {
+		needed = DefaultCookingTime
+	}
+	return end.Add(-needed), end, nil
+}
@@ -0,0 +1,110 @@
+package mealplan
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+func testPlan(t *testing.T) (*models.Plan, []Planned) {
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipes := []*models.Recipe{
+		{
+			ID: "1", Name: "Slow Cooker Mashed Potatoes", URL: "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/",
+			Servings: 6, PrepTime: models.Duration(20 * time.Minute), CookTime: models.Duration(4 * time.Hour),
+			Ingredients: models.IngredientGroups{{Ingredients: []models.Ingredient{
+				{Amount: "3", Unit: "lbs.", Name: "russet potatoes", Cost: &models.Money{Cents: 300, Currency: "USD"}},
+			}}},
+		},
+		{
+			ID: "2", Name: "Toast", URL: "https://example.com/toast",
+			Ingredients: models.IngredientGroups{{Ingredients: []models.Ingredient{{Amount: "2", Unit: "slices", Name: "bread"}}}},
+		},
+	}
+	for _, rcp := range recipes {
+		if err = s.Insert(context.Background(), rcp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan := &models.Plan{ID: "week-43", Name: "Week 43, the spooky one"}
+	plan.Set(models.Meal{Date: "2022-10-24", Slot: models.Dinner, RecipeID: "1", Servings: 12})
+	plan.Set(models.Meal{Date: "2022-10-24", Slot: models.Breakfast, RecipeID: "2"})
+	plan.Set(models.Meal{Date: "2022-10-26", Slot: models.Dinner, RecipeID: "1"})
+	planned, err := Load(context.Background(), s, plan.Meals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan, planned
+}
+
+func TestLoad(t *testing.T) {
+	_, planned := testPlan(t)
+	if len(planned) != 3 || planned[0].Recipe.Name != "Toast" {
+		t.Fatalf("got %d meals, want 3 in order", len(planned))
+	}
+	// the same recipe is scaled separately for each meal
+	if planned[1].Recipe.Servings != 12 || planned[2].Recipe.Servings != 6 {
+		t.Errorf("servings = %v and %v, want 12 and 6", planned[1].Recipe.Servings, planned[2].Recipe.Servings)
+	}
+}
+
+func TestTotalCost(t *testing.T) {
+	_, planned := testPlan(t)
+	got := TotalCost(planned)
+	if got.Total == nil || got.Total.Cents != 900 {
+		t.Errorf("total = %v, want $9.00", got.Total)
+	}
+	if len(got.Unpriced) != 1 || got.Unpriced[0] != "2022-10-24 breakfast" {
+		t.Errorf("unpriced = %v, want the breakfast", got.Unpriced)
+	}
+
+	list := ShoppingList(planned[1:], 0)
+	if it := list.Sections[0].Items[0]; it.Amounts[0].String() != "9 lb." {
+		t.Errorf("shopping list has %v %s, want 9 lb. of potatoes", it.Amounts, it.Name)
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	plan, planned := testPlan(t)
+	var b bytes.Buffer
+	if err := WriteICS(&b, plan, planned, time.Date(2022, 10, 20, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	ics := b.String()
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"X-WR-CALNAME:Week 43\\, the spooky one",
+		// ready for dinner at 6pm, after 20 minutes of prep and 4 hours of cooking
+		"DTSTART:20221024T134000",
+		"DTEND:20221024T180000",
+		"SUMMARY:Dinner: Slow Cooker Mashed Potatoes",
+		"DESCRIPTION:Servings: 12\\nPrep time: 20 mins\\nCook time: 4 hrs\\nhttps://www",
+		" .budgetbytes.com/slow-cooker-mashed-potatoes/", // folded
+		// recipes without times take DefaultCookingTime
+		"DTSTART:20221024T073000",
+		"DTSTAMP:20221020T120000Z",
+		"END:VCALENDAR",
+	} {
+		if !strings.Contains(ics, line+"\r\n") {
+			t.Errorf("calendar is missing %q:\n%s", line, ics)
+		}
+	}
+	if n := strings.Count(ics, "BEGIN:VEVENT"); n != 3 {
+		t.Errorf("calendar has %d events, want 3", n)
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+}
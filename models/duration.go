@@ -0,0 +1,166 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Duration is a length of time such as a recipe's prep or cook time.
+// In JSON it is written as an ISO 8601 duration (eg. "PT1H15M"), which is what schema.org uses.
+// In BSON it is stored as a whole number of minutes so that it can be compared in queries.
+type Duration time.Duration
+
+// Minutes returns the duration as a whole number of minutes, rounded to the nearest minute.
+func (d Duration) Minutes() int64 {
+	return int64(time.Duration(d).Round(time.Minute) / time.Minute)
+}
+
+// String formats the duration in ISO 8601, eg. "PT1H15M". A zero duration is formatted as "PT0M".
+func (d Duration) String() string {
+	minutes := d.Minutes()
+	var b strings.Builder
+	b.WriteString("P")
+	if days := minutes / (24 * 60); days > 0 {
+		b.WriteString(strconv.FormatInt(days, 10) + "D")
+		minutes -= days * 24 * 60
+	}
+	b.WriteString("T")
+	if hours := minutes / 60; hours > 0 {
+		b.WriteString(strconv.FormatInt(hours, 10) + "H")
+		minutes -= hours * 60
+	}
+	if minutes > 0 || b.Len() == 2 {
+		b.WriteString(strconv.FormatInt(minutes, 10) + "M")
+	}
+	return b.String()
+}
+
+// ParseDuration reads an ISO 8601 duration such as "PT15M" or "P1DT2H30M". Go-style durations
+// (eg. "1h15m") are also accepted. Years and months are not supported since their length varies.
+func ParseDuration(s string) (Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if s[0] != 'P' && s[0] != 'p' {
+		d, err := time.ParseDuration(s)
+		return Duration(d), err
+	}
+
+	var (
+		total  time.Duration
+		inTime bool
+		num    strings.Builder
+	)
+	for _, r := range strings.ToUpper(s[1:]) {
+		switch {
+		case r >= '0' && r <= '9' || r == '.' || r == ',':
+			if r == ',' {
+				r = '.'
+			}
+			num.WriteRune(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.ParseFloat(num.String(), 64)
+		if err != nil {
+			return 0, errors.New("invalid ISO 8601 duration: " + s)
+		}
+		num.Reset()
+
+		var unit time.Duration
+		switch {
+		case r == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case r == 'H' && inTime:
+			unit = time.Hour
+		case r == 'M' && inTime:
+			unit = time.Minute
+		case r == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, errors.New("unsupported ISO 8601 duration: " + s)
+		}
+		total += time.Duration(n * float64(unit))
+	}
+	if num.Len() > 0 {
+		return 0, errors.New("invalid ISO 8601 duration: " + s)
+	}
+	return Duration(total), nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a duration string, or a number of minutes.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var minutes float64
+	if err := json.Unmarshal(data, &minutes); err == nil {
+		*d = Duration(minutes * float64(time.Minute))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Duration) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.Int64, bsoncore.AppendInt64(nil, d.Minutes()), nil
+}
+
+func (d *Duration) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	v := bsoncore.Value{Type: t, Data: data}
+	switch t {
+	case bsontype.Int32, bsontype.Int64:
+		minutes, ok := v.AsInt64OK()
+		if !ok {
+			return errors.New("invalid BSON duration")
+		}
+		*d = Duration(time.Duration(minutes) * time.Minute)
+	case bsontype.Double:
+		minutes, ok := v.DoubleOK()
+		if !ok {
+			return errors.New("invalid BSON duration")
+		}
+		*d = Duration(minutes * float64(time.Minute))
+	case bsontype.String:
+		parsed, err := ParseDuration(v.StringValue())
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case bsontype.Null, bsontype.Undefined:
+		*d = 0
+	default:
+		return errors.New("invalid BSON duration type " + t.String())
+	}
+	return nil
+}
+
+// TimeNeeded returns how long the recipe takes to make: its total time, or the prep and cook times
+// added together if it doesn't give a total.
+func (r *Recipe) TimeNeeded() Duration {
+	if r.TotalTime > 0 {
+		return r.TotalTime
+	}
+	return r.PrepTime + r.CookTime
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// IngredientGroup is a named section of a recipe's ingredients (eg. "Sauce", "Garnish").
+// Recipes that don't split their ingredients will have a single group with an empty name.
+type IngredientGroup struct {
+	Name        string       `json:"name" bson:"name"`
+	Ingredients []Ingredient `json:"ingredients" bson:"ingredients"`
+}
+
+// IngredientGroups is the list of ingredient groups on a recipe.
+// Older recipes were saved as a flat list of ingredients, so both shapes are accepted when
+// decoding. The flat shape is read as a single unnamed group.
+type IngredientGroups []IngredientGroup
+
+// Flatten returns the ingredients of every group in order.
+func (g IngredientGroups) Flatten() []Ingredient {
+	var ingredients []Ingredient
+	for _, group := range g {
+		ingredients = append(ingredients, group.Ingredients...)
+	}
+	return ingredients
+}
+
+func (g *IngredientGroups) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*g = nil
+		return nil
+	}
+
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if !isGrouped(len(raw), func(i int) bool { _, ok := raw[i]["ingredients"]; return ok }) {
+		var flat []Ingredient
+		if err := json.Unmarshal(data, &flat); err != nil {
+			return err
+		}
+		*g = IngredientGroups{{Ingredients: flat}}
+		return nil
+	}
+
+	var groups []IngredientGroup
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return err
+	}
+	*g = groups
+	return nil
+}
+
+func (g *IngredientGroups) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bsontype.Null || t == bsontype.Undefined {
+		*g = nil
+		return nil
+	}
+	if t != bsontype.Array {
+		return errors.New("ingredients: expected BSON array, got " + t.String())
+	}
+
+	values, err := bson.Raw(data).Values()
+	if err != nil {
+		return err
+	}
+	docs := make([]bson.Raw, len(values))
+	for i, v := range values {
+		doc, ok := v.DocumentOK()
+		if !ok {
+			return errors.New("ingredients: expected BSON document, got " + v.Type.String())
+		}
+		docs[i] = doc
+	}
+
+	grouped := isGrouped(len(docs), func(i int) bool {
+		_, err := docs[i].LookupErr("ingredients")
+		return err == nil
+	})
+
+	var groups IngredientGroups
+	if !grouped {
+		groups = IngredientGroups{{Ingredients: make([]Ingredient, len(docs))}}
+	}
+	for i, doc := range docs {
+		if grouped {
+			var group IngredientGroup
+			if err := bson.Unmarshal(doc, &group); err != nil {
+				return err
+			}
+			groups = append(groups, group)
+		} else if err := bson.Unmarshal(doc, &groups[0].Ingredients[i]); err != nil {
+			return err
+		}
+	}
+	*g = groups
+	return nil
+}
+
+// isGrouped reports whether a decoded list is in the grouped shape, ie. every element has an
+// "ingredients" key. An empty list is treated as grouped.
+func isGrouped(n int, hasIngredients func(i int) bool) bool {
+	for i := 0; i < n; i++ {
+		if !hasIngredients(i) {
+			return false
+		}
+	}
+	return true
+}
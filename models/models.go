@@ -6,19 +6,44 @@ import (
 )
 
 type Ingredient struct {
-	Amount string `json:"amount"`
-	Unit   string `json:"unit"`
-	Name   string `json:"name"`
-	Notes  string `json:"notes"`
+	// Amount is the amount as written on the recipe. See Quantity for its numeric value.
+	Amount string `json:"amount" bson:"amount"`
+	Unit   string `json:"unit" bson:"unit"`
+	Name   string `json:"name" bson:"name"`
+	Notes  string `json:"notes" bson:"notes"`
+	// Cost is the price of the ingredient, if the recipe gives one.
+	Cost *Money `json:"cost,omitempty" bson:"cost,omitempty"`
+	// Quantity is the numeric form of Amount. It is nil if there is no amount.
+	Quantity *Quantity `json:"quantity,omitempty" bson:"quantity,omitempty"`
 }
 
 type Recipe struct {
-	ID           string       `json:"id"`
-	Name         string       `json:"name"`
-	URL          string       `json:"url"`
-	Image        string       `json:"image"`
-	Ingredients  []Ingredient `json:"ingredients"`
-	Instructions []string     `json:"instructions"`
+	ID           string           `json:"id" bson:"id"`
+	Name         string           `json:"name" bson:"name"`
+	URL          string           `json:"url" bson:"url"`
+	Image        string           `json:"image" bson:"image"`
+	Ingredients  IngredientGroups `json:"ingredients" bson:"ingredients"`
+	Instructions []string         `json:"instructions" bson:"instructions"`
+
+	// Metadata from the recipe card. Not every recipe fills in every field.
+	Summary      string   `json:"summary,omitempty" bson:"summary,omitempty"`
+	Author       string   `json:"author,omitempty" bson:"author,omitempty"`
+	PrepTime     Duration `json:"prepTime,omitempty" bson:"prepTime,omitempty"`
+	CookTime     Duration `json:"cookTime,omitempty" bson:"cookTime,omitempty"`
+	TotalTime    Duration `json:"totalTime,omitempty" bson:"totalTime,omitempty"`
+	Servings     float64  `json:"servings,omitempty" bson:"servings,omitempty"`
+	ServingsUnit string   `json:"servingsUnit,omitempty" bson:"servingsUnit,omitempty"`
+	Course       []string `json:"course,omitempty" bson:"course,omitempty"`
+	Cuisine      []string `json:"cuisine,omitempty" bson:"cuisine,omitempty"`
+	Keywords     []string `json:"keywords,omitempty" bson:"keywords,omitempty"`
+
+	Nutrition *Nutrition  `json:"nutrition,omitempty" bson:"nutrition,omitempty"`
+	Cost      *RecipeCost `json:"cost,omitempty" bson:"cost,omitempty"`
+}
+
+// AllIngredients returns every ingredient in the recipe regardless of which group it belongs to.
+func (r *Recipe) AllIngredients() []Ingredient {
+	return r.Ingredients.Flatten()
 }
 
 func (r *Recipe) SaveAs(path string) error {
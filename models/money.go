@@ -0,0 +1,153 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of money, stored in cents (or the smallest unit of the currency) to avoid
+// rounding errors when prices are added up.
+type Money struct {
+	Cents    int64  `json:"cents" bson:"cents"`
+	Currency string `json:"currency" bson:"currency"` // ISO 4217 code, eg. "USD"
+}
+
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"£": "GBP",
+	"€": "EUR",
+}
+
+// ParseMoney reads a price written with a currency symbol, eg. "$1.80" or "$1,200".
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	for symbol, currency := range currencySymbols {
+		if !strings.HasPrefix(s, symbol) {
+			continue
+		}
+		num := strings.ReplaceAll(strings.TrimSpace(s[len(symbol):]), ",", "")
+		whole, frac, _ := strings.Cut(num, ".")
+		if len(frac) > 2 {
+			frac = frac[:2]
+		}
+		for len(frac) < 2 {
+			frac += "0"
+		}
+		cents, err := strconv.ParseInt(whole+frac, 10, 64)
+		if err != nil {
+			return Money{}, errors.New("invalid price: " + s)
+		}
+		return Money{Cents: cents, Currency: currency}, nil
+	}
+	return Money{}, errors.New("price has no currency symbol: " + s)
+}
+
+// String formats the amount with its currency symbol, eg. "$1.80".
+func (m Money) String() string {
+	symbol := m.Currency + " "
+	for sym, currency := range currencySymbols {
+		if currency == m.Currency {
+			symbol = sym
+		}
+	}
+	sign := ""
+	cents := m.Cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%s%d.%02d", sign, symbol, cents/100, cents%100)
+}
+
+// RecipeCost is the price of a recipe as stated on the recipe card, along with the total of the
+// prices given for each ingredient.
+type RecipeCost struct {
+	Recipe      *Money `json:"recipe,omitempty" bson:"recipe,omitempty"`
+	Serving     *Money `json:"serving,omitempty" bson:"serving,omitempty"`
+	Ingredients *Money `json:"ingredients,omitempty" bson:"ingredients,omitempty"`
+	// Mismatch is set when the ingredient prices don't add up to the stated recipe cost.
+	Mismatch bool `json:"mismatch,omitempty" bson:"mismatch,omitempty"`
+}
+
+// IngredientsCost adds up the price of every ingredient that has one. The boolean is false if no
+// ingredient has a price, or if the prices use different currencies.
+func (r *Recipe) IngredientsCost() (Money, bool) {
+	var total *Money
+	for _, ingredient := range r.AllIngredients() {
+		if ingredient.Cost == nil {
+			continue
+		}
+		if total == nil {
+			total = &Money{Currency: ingredient.Cost.Currency}
+		}
+		if total.Currency != ingredient.Cost.Currency {
+			return Money{}, false
+		}
+		total.Cents += ingredient.Cost.Cents
+	}
+	if total == nil {
+		return Money{}, false
+	}
+	return *total, true
+}
+
+// The maximum difference between the stated recipe cost and the total of the ingredient prices
+// before it is flagged. Each price on the card is rounded, so the tolerance grows with the number of
+// ingredients.
+const (
+	minCostTolerance        = 5 // cents
+	costToleranceIngredient = 1 // cents
+)
+
+// TotalCost adds up the ingredient prices into Cost.Ingredients, and flags the recipe with
+// Cost.Mismatch if they don't match the stated cost. It is called whenever the prices change, eg.
+// after parsing or scaling.
+func (r *Recipe) TotalCost() {
+	total, ok := r.IngredientsCost()
+	if !ok {
+		return
+	}
+	if r.Cost == nil {
+		r.Cost = &RecipeCost{}
+	}
+	r.Cost.Ingredients = &total
+	r.Cost.Mismatch = false
+
+	stated := r.Cost.Recipe
+	if stated == nil || stated.Currency != total.Currency {
+		return
+	}
+	priced := 0
+	for _, ingredient := range r.AllIngredients() {
+		if ingredient.Cost != nil {
+			priced++
+		}
+	}
+	tolerance := int64(priced * costToleranceIngredient)
+	if tolerance < minCostTolerance {
+		tolerance = minCostTolerance
+	}
+	diff := total.Cents - stated.Cents
+	r.Cost.Mismatch = diff > tolerance || diff < -tolerance
+}
+
+// CostPerServing returns the cost of a single serving. The stated cost per serving is used if the
+// recipe has one, otherwise the recipe cost (or the total of the ingredient prices) is divided by
+// the number of servings. The boolean is false if there isn't enough information.
+func (r *Recipe) CostPerServing() (Money, bool) {
+	if r.Cost == nil {
+		return Money{}, false
+	}
+	if r.Cost.Serving != nil {
+		return *r.Cost.Serving, true
+	}
+	total := r.Cost.Recipe
+	if total == nil {
+		total = r.Cost.Ingredients
+	}
+	if total == nil || r.Servings <= 0 {
+		return Money{}, false
+	}
+	return *total.mul(1 / r.Servings), true
+}
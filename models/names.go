@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words that describe how an ingredient is prepared or bought, rather than what it is. They are
+// dropped when normalizing names so that eg. "freshly cracked black pepper" and "black pepper" are
+// the same ingredient.
+var preparationWords = map[string]bool{
+	"fresh": true, "freshly": true, "cracked": true, "ground": true,
+	"chopped": true, "minced": true, "diced": true, "sliced": true, "shredded": true, "grated": true,
+	"crushed": true, "peeled": true, "divided": true, "optional": true,
+	"large": true, "medium": true, "small": true,
+	"warm": true, "warmed": true, "cold": true, "softened": true, "melted": true,
+	"extra": true, "virgin": true,
+}
+
+// Plurals that don't follow the rules in singular.
+var irregularPlurals = map[string]string{
+	"leaves": "leaf",
+	"halves": "half",
+	"knives": "knife",
+}
+
+// NormalizeName reduces an ingredient name to a form that can be compared with other names, eg.
+// "Russet Potatoes" and "russet potato, peeled" both become "russet potato". Notes in brackets or
+// after a comma are removed, along with words describing how the ingredient is prepared, and every
+// word is made singular.
+func NormalizeName(name string) string {
+	name = strings.ToLower(name)
+	// remove "(...)"
+	for {
+		start := strings.Index(name, "(")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(name[start:], ")")
+		if end < 0 {
+			name = name[:start]
+			break
+		}
+		name = name[:start] + " " + name[start+end+1:]
+	}
+	name, _, _ = strings.Cut(name, ",")
+	name = strings.ReplaceAll(name, "to taste", " ")
+
+	var words []string
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	}) {
+		if word = strings.Trim(word, "-'"); word != "" && !preparationWords[word] {
+			words = append(words, singular(word))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizedName returns the ingredient's name as given by NormalizeName.
+func (i Ingredient) NormalizedName() string {
+	return NormalizeName(i.Name)
+}
+
+// singular guesses the singular form of a lowercase English word. It only needs to be consistent,
+// so that the singular and plural forms of a name compare equal.
+func singular(word string) string {
+	if s, ok := irregularPlurals[word]; ok {
+		return s
+	}
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+	return word
+}
@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"russet potatoes", "russet potato"},
+		{"Russet Potato, peeled and cubed", "russet potato"},
+		{"Freshly cracked black pepper", "black pepper"},
+		{"salt to taste (about 3/4 tsp)", "salt"},
+		{"salt (for cooking water)", "salt"},
+		{"extra virgin olive oil", "olive oil"},
+		{"butter ", "butter"},
+		{"fresh bay leaves", "bay leaf"},
+		{"strawberries", "strawberry"},
+		{"tomatoes", "tomato"},
+		{"boxes of couscous", "box of couscous"},
+		{"all-purpose flour", "all-purpose flour"},
+		{"(optional)", ""},
+	}
+	for _, test := range tests {
+		if got := NormalizeName(test.name); got != test.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import "strconv"
+
+// Measure is an amount with an explicit unit, eg. 269 "kcal" or 380 "mg".
+type Measure struct {
+	Value float64 `json:"value" bson:"value"`
+	Unit  string  `json:"unit" bson:"unit"`
+}
+
+func (m Measure) String() string {
+	s := strconv.FormatFloat(m.Value, 'f', -1, 64)
+	if m.Unit == "" {
+		return s
+	}
+	return s + " " + m.Unit
+}
+
+// Nutrition is the nutrition information for a single serving of a recipe.
+// Values that the recipe doesn't give are left nil.
+type Nutrition struct {
+	ServingSize   *Measure `json:"servingSize,omitempty" bson:"servingSize,omitempty"`
+	Calories      *Measure `json:"calories,omitempty" bson:"calories,omitempty"`
+	Carbohydrates *Measure `json:"carbohydrates,omitempty" bson:"carbohydrates,omitempty"`
+	Protein       *Measure `json:"protein,omitempty" bson:"protein,omitempty"`
+	Fat           *Measure `json:"fat,omitempty" bson:"fat,omitempty"`
+	SaturatedFat  *Measure `json:"saturatedFat,omitempty" bson:"saturatedFat,omitempty"`
+	Cholesterol   *Measure `json:"cholesterol,omitempty" bson:"cholesterol,omitempty"`
+	Sodium        *Measure `json:"sodium,omitempty" bson:"sodium,omitempty"`
+	Potassium     *Measure `json:"potassium,omitempty" bson:"potassium,omitempty"`
+	Fiber         *Measure `json:"fiber,omitempty" bson:"fiber,omitempty"`
+	Sugar         *Measure `json:"sugar,omitempty" bson:"sugar,omitempty"`
+}
+
+// NutritionFields lists every value in Nutrition. The name matches the field's JSON/BSON key.
+var NutritionFields = []struct {
+	Name  string
+	Field func(n *Nutrition) **Measure
+}{
+	{"servingSize", func(n *Nutrition) **Measure { return &n.ServingSize }},
+	{"calories", func(n *Nutrition) **Measure { return &n.Calories }},
+	{"carbohydrates", func(n *Nutrition) **Measure { return &n.Carbohydrates }},
+	{"protein", func(n *Nutrition) **Measure { return &n.Protein }},
+	{"fat", func(n *Nutrition) **Measure { return &n.Fat }},
+	{"saturatedFat", func(n *Nutrition) **Measure { return &n.SaturatedFat }},
+	{"cholesterol", func(n *Nutrition) **Measure { return &n.Cholesterol }},
+	{"sodium", func(n *Nutrition) **Measure { return &n.Sodium }},
+	{"potassium", func(n *Nutrition) **Measure { return &n.Potassium }},
+	{"fiber", func(n *Nutrition) **Measure { return &n.Fiber }},
+	{"sugar", func(n *Nutrition) **Measure { return &n.Sugar }},
+}
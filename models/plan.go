@@ -0,0 +1,161 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DateFormat is how meal dates are written, eg. "2022-10-24".
+const DateFormat = "2006-01-02"
+
+// Slot is the meal of the day that a recipe is planned for.
+type Slot string
+
+const (
+	Breakfast Slot = "breakfast"
+	Lunch     Slot = "lunch"
+	Snack     Slot = "snack"
+	Dinner    Slot = "dinner"
+)
+
+// Slots lists every slot in the order they happen during the day.
+var Slots = []Slot{Breakfast, Lunch, Snack, Dinner}
+
+// ParseSlot reads a slot name, ignoring case.
+func ParseSlot(s string) (Slot, error) {
+	slot := Slot(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Slots {
+		if slot == known {
+			return slot, nil
+		}
+	}
+	return "", errors.New(`unknown meal "` + s + `", use "breakfast", "lunch", "snack" or "dinner"`)
+}
+
+// Time returns the usual time of day for the meal, as an offset from midnight.
+func (s Slot) Time() time.Duration {
+	switch s {
+	case Breakfast:
+		return 8 * time.Hour
+	case Lunch:
+		return 12 * time.Hour
+	case Snack:
+		return 15 * time.Hour
+	}
+	return 18 * time.Hour
+}
+
+func (s Slot) order() int {
+	for i, known := range Slots {
+		if s == known {
+			return i
+		}
+	}
+	return len(Slots)
+}
+
+// Meal is a recipe planned for a meal.
+type Meal struct {
+	Date     string `json:"date" bson:"date"` // see DateFormat
+	Slot     Slot   `json:"slot" bson:"slot"`
+	RecipeID string `json:"recipeId" bson:"recipeId"`
+	// Servings is how many servings to make. Zero makes the recipe as written.
+	Servings float64 `json:"servings,omitempty" bson:"servings,omitempty"`
+}
+
+// Day returns the meal's date at midnight UTC.
+func (m Meal) Day() (time.Time, error) {
+	return time.Parse(DateFormat, m.Date)
+}
+
+// Plan is a meal plan, eg. for a week. Each meal slot of a day holds at most one recipe.
+type Plan struct {
+	// ID names the plan, eg. "week-43". It may only use letters, digits, "-" and "_", so that it
+	// can be used as a file name.
+	ID    string `json:"id" bson:"id"`
+	Name  string `json:"name,omitempty" bson:"name,omitempty"`
+	Meals []Meal `json:"meals" bson:"meals"`
+}
+
+var planID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Validate checks the plan's ID and every meal. Slots are written the way ParseSlot reads them (eg.
+// "Dinner" becomes "dinner"), so that a day can't hold both.
+func (p *Plan) Validate() error {
+	if !planID.MatchString(p.ID) {
+		return errors.New(`invalid plan id "` + p.ID + `", use only letters, digits, "-" and "_"`)
+	}
+	seen := make(map[string]bool)
+	for i := range p.Meals {
+		m := &p.Meals[i]
+		if _, err := m.Day(); err != nil {
+			return errors.New(`invalid date "` + m.Date + `", use YYYY-MM-DD`)
+		}
+		slot, err := ParseSlot(string(m.Slot))
+		if err != nil {
+			return err
+		}
+		m.Slot = slot
+		if m.RecipeID == "" {
+			return errors.New("no recipe for " + string(m.Slot) + " on " + m.Date)
+		}
+		if m.Servings < 0 {
+			return errors.New("servings can't be negative")
+		}
+		key := m.Date + " " + string(m.Slot)
+		if seen[key] {
+			return errors.New("more than one recipe for " + string(m.Slot) + " on " + m.Date)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Sort orders the meals by date and time of day.
+func (p *Plan) Sort() {
+	sort.SliceStable(p.Meals, func(i, j int) bool {
+		a, b := p.Meals[i], p.Meals[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return a.Slot.order() < b.Slot.order()
+	})
+}
+
+// Set puts the meal in the plan, replacing whatever was planned for the same slot.
+func (p *Plan) Set(meal Meal) {
+	for i, m := range p.Meals {
+		if m.Date == meal.Date && m.Slot == meal.Slot {
+			p.Meals[i] = meal
+			return
+		}
+	}
+	p.Meals = append(p.Meals, meal)
+	p.Sort()
+}
+
+// Remove takes the meal planned for the slot out of the plan. It reports whether there was one.
+func (p *Plan) Remove(date string, slot Slot) bool {
+	for i, m := range p.Meals {
+		if m.Date == date && m.Slot == slot {
+			p.Meals = append(p.Meals[:i], p.Meals[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Between returns the meals from one date to another, including both. An empty date leaves that end
+// of the range open.
+func (p *Plan) Between(from, to string) []Meal {
+	var meals []Meal
+	for _, m := range p.Meals {
+		if (from == "" || m.Date >= from) && (to == "" || m.Date <= to) {
+			meals = append(meals, m)
+		}
+	}
+	return meals
+}
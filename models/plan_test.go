@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestValidatePlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		meals []Meal
+		ok    bool
+	}{
+		{"valid", []Meal{{Date: "2022-10-24", Slot: Dinner, RecipeID: "30990"}, {Date: "2022-10-24", Slot: Lunch, RecipeID: "30990"}}, true},
+		{"bad date", []Meal{{Date: "10/24/2022", Slot: Dinner, RecipeID: "30990"}}, false},
+		{"unknown slot", []Meal{{Date: "2022-10-24", Slot: "brunch", RecipeID: "30990"}}, false},
+		{"no recipe", []Meal{{Date: "2022-10-24", Slot: Dinner}}, false},
+		{"negative servings", []Meal{{Date: "2022-10-24", Slot: Dinner, RecipeID: "30990", Servings: -1}}, false},
+		{"same slot", []Meal{{Date: "2022-10-24", Slot: Dinner, RecipeID: "30990"}, {Date: "2022-10-24", Slot: Dinner, RecipeID: "31002"}}, false},
+		{"same slot in another case", []Meal{{Date: "2022-10-24", Slot: "Dinner", RecipeID: "30990"}, {Date: "2022-10-24", Slot: "dinner", RecipeID: "31002"}}, false},
+	}
+
+	for _, test := range tests {
+		p := &Plan{ID: "week-43", Meals: test.meals}
+		if err := p.Validate(); (err == nil) != test.ok {
+			t.Errorf("%s: Validate() = %v", test.name, err)
+		}
+	}
+
+	p := &Plan{ID: "week-43", Meals: []Meal{{Date: "2022-10-24", Slot: " Dinner", RecipeID: "30990"}}}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Meals[0].Slot != Dinner {
+		t.Errorf("slot = %q, want %q", p.Meals[0].Slot, Dinner)
+	}
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+// Quantity is the numeric form of an ingredient's amount, eg. "1 1/2" is 1.5 and "2-3" is the range
+// 2 to 3. The original text stays in Ingredient.Amount.
+type Quantity struct {
+	Value float64 `json:"value" bson:"value"`
+	// Max is the upper end of a range such as "2-3". It is zero if the quantity isn't a range.
+	Max float64 `json:"max,omitempty" bson:"max,omitempty"`
+	// ToTaste marks amounts like "to taste" that have no number. Value is zero.
+	ToTaste bool `json:"toTaste,omitempty" bson:"toTaste,omitempty"`
+}
+
+// IsRange reports whether the quantity is a range like "2-3".
+func (q Quantity) IsRange() bool {
+	return q.Max > q.Value
+}
+
+// Scalable reports whether the quantity has a number that can be multiplied, eg. for scaling the
+// recipe. Amounts like "to taste" can't be scaled.
+func (q Quantity) Scalable() bool {
+	return !q.ToTaste && q.Value > 0
+}
+
+// Mul returns the quantity multiplied by the factor. Quantities that aren't scalable are returned
+// unchanged.
+func (q Quantity) Mul(factor float64) Quantity {
+	if !q.Scalable() {
+		return q
+	}
+	q.Value *= factor
+	q.Max *= factor
+	return q
+}
+
+// String formats the quantity the way a recipe would, using fractions where they are close enough,
+// eg. "1 1/2", "2-3" or "to taste".
+func (q Quantity) String() string {
+	if q.ToTaste {
+		return "to taste"
+	}
+	if q.IsRange() {
+		return units.FormatAmount(q.Value) + "-" + units.FormatAmount(q.Max)
+	}
+	return units.FormatAmount(q.Value)
+}
+
+var vulgarFractions = map[rune]string{
+	'½': "1/2", '⅓': "1/3", '⅔': "2/3", '¼': "1/4", '¾': "3/4",
+	'⅕': "1/5", '⅖': "2/5", '⅗': "3/5", '⅘': "4/5", '⅙': "1/6", '⅚': "5/6",
+	'⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+// Amounts that mean "use as much as you like".
+var toTaste = []string{"to taste", "as needed", "to serve", "for serving", "for garnish"}
+
+// ParseQuantity reads an amount such as "3", "1.5", "1/4", "1 1/2", "½", "1½", "2-3", "2 to 3" or
+// "to taste". An empty amount gives a zero Quantity and no error.
+func ParseQuantity(amount string) (Quantity, error) {
+	s := strings.ToLower(strings.TrimSpace(amount))
+	if s == "" {
+		return Quantity{}, nil
+	}
+	for _, phrase := range toTaste {
+		if s == phrase {
+			return Quantity{ToTaste: true}, nil
+		}
+	}
+
+	// Normalize the text so that only ASCII fractions and "-" for ranges are left.
+	var b strings.Builder
+	for _, r := range s {
+		if f, ok := vulgarFractions[r]; ok {
+			b.WriteString(" " + f)
+			continue
+		}
+		switch r {
+		case '⁄':
+			b.WriteRune('/')
+		case '–', '—':
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	s = strings.ReplaceAll(b.String(), " to ", "-")
+
+	parts := strings.Split(s, "-")
+	if len(parts) > 2 {
+		return Quantity{}, errors.New("invalid amount: " + amount)
+	}
+	min, err := parseMixed(parts[0])
+	if err != nil {
+		return Quantity{}, errors.New("invalid amount: " + amount)
+	}
+	q := Quantity{Value: min}
+	if len(parts) == 2 {
+		max, err := parseMixed(parts[1])
+		if err != nil || max < min {
+			return Quantity{}, errors.New("invalid amount: " + amount)
+		}
+		if max > min {
+			q.Max = max
+		}
+	}
+	return q, nil
+}
+
+// parseMixed reads a whole number, decimal, fraction or mixed number like "1 1/2".
+func parseMixed(s string) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, errors.New("invalid number")
+	}
+	var total float64
+	for i, field := range fields {
+		if num, den, found := strings.Cut(field, "/"); found {
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, err
+			}
+			d, err := strconv.ParseFloat(den, 64)
+			if err != nil || d == 0 {
+				return 0, errors.New("invalid fraction")
+			}
+			total += n / d
+			continue
+		}
+		if i > 0 {
+			// Only the first part of a mixed number can be a whole number
+			return 0, errors.New("invalid number")
+		}
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	if total < 0 || math.IsInf(total, 0) || math.IsNaN(total) {
+		return 0, errors.New("invalid number")
+	}
+	return total, nil
+}
+
+// ParseAmount sets the ingredient's Quantity from its Amount. Ingredients without an amount whose
+// name or notes say "to taste" are marked as such, and ingredients without any amount are left
+// with a nil Quantity.
+func (i *Ingredient) ParseAmount() error {
+	i.Quantity = nil
+	q, err := ParseQuantity(i.Amount)
+	if err != nil {
+		return err
+	}
+	if i.Amount == "" {
+		text := strings.ToLower(i.Name + " " + i.Notes)
+		if !strings.Contains(text, "to taste") {
+			return nil
+		}
+		q.ToTaste = true
+	}
+	i.Quantity = &q
+	return nil
+}
@@ -0,0 +1,74 @@
+package models
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   Quantity
+		text   string
+	}{
+		{"3", Quantity{Value: 3}, "3"},
+		{"1.5", Quantity{Value: 1.5}, "1 1/2"},
+		{"1/4", Quantity{Value: 0.25}, "1/4"},
+		{"1 1/2", Quantity{Value: 1.5}, "1 1/2"},
+		{"½", Quantity{Value: 0.5}, "1/2"},
+		{"1½", Quantity{Value: 1.5}, "1 1/2"},
+		{"1 ⅓", Quantity{Value: 4.0 / 3}, "1 1/3"},
+		{"1⁄8", Quantity{Value: 0.125}, "1/8"},
+		{"2-3", Quantity{Value: 2, Max: 3}, "2-3"},
+		{"2–3", Quantity{Value: 2, Max: 3}, "2-3"},
+		{"2 to 3", Quantity{Value: 2, Max: 3}, "2-3"},
+		{"½-1", Quantity{Value: 0.5, Max: 1}, "1/2-1"},
+		{"2-2", Quantity{Value: 2}, "2"},
+		{"0.3", Quantity{Value: 0.3}, "0.3"},
+		{"To taste", Quantity{ToTaste: true}, "to taste"},
+		{"", Quantity{}, "0"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseQuantity(test.amount)
+		if err != nil {
+			t.Errorf("ParseQuantity(%q) returned error: %v", test.amount, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseQuantity(%q) = %+v, want %+v", test.amount, got, test.want)
+		}
+		if got.String() != test.text {
+			t.Errorf("ParseQuantity(%q).String() = %q, want %q", test.amount, got.String(), test.text)
+		}
+	}
+}
+
+func TestParseQuantityInvalid(t *testing.T) {
+	tests := []string{"a few", "1/0", "3-2", "1-2-3", "1 2", "1/2 1"}
+
+	for _, test := range tests {
+		if q, err := ParseQuantity(test); err == nil {
+			t.Errorf("ParseQuantity(%q) = %+v, want error", test, q)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		ingredient Ingredient
+		want       *Quantity
+	}{
+		{Ingredient{Amount: "2", Name: "cloves garlic"}, &Quantity{Value: 2}},
+		{Ingredient{Name: "salt and pepper", Notes: "to taste"}, &Quantity{ToTaste: true}},
+		{Ingredient{Name: "water"}, nil},
+	}
+
+	for _, test := range tests {
+		if err := test.ingredient.ParseAmount(); err != nil {
+			t.Errorf("ParseAmount(%+v) returned error: %v", test.ingredient, err)
+			continue
+		}
+		got := test.ingredient.Quantity
+		if (got == nil) != (test.want == nil) || got != nil && *got != *test.want {
+			t.Errorf("ParseAmount(%+v) = %v, want %v", test.ingredient, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+// Scale multiplies the recipe by the factor, eg. 2 to double it or 0.5 to halve it.
+// Ingredient amounts are rounded back to kitchen fractions and moved to a more readable unit where
+// needed (eg. 48 tsp becomes 1 cup). Ingredient prices, the recipe cost and the number of servings
+// are scaled too. Per-serving values (cost per serving and nutrition) don't change.
+func (r *Recipe) Scale(factor float64) error {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return errors.New("scale factor must be a positive number")
+	}
+	for g := range r.Ingredients {
+		for i := range r.Ingredients[g].Ingredients {
+			r.Ingredients[g].Ingredients[i].Scale(factor)
+		}
+	}
+	r.Servings *= factor
+
+	if r.Cost != nil {
+		cost := *r.Cost
+		cost.Recipe = cost.Recipe.mul(factor)
+		r.Cost = &cost
+		r.TotalCost()
+	}
+	return nil
+}
+
+// ScaleToServings scales the recipe so that it makes the given number of servings.
+func (r *Recipe) ScaleToServings(servings float64) error {
+	if r.Servings <= 0 {
+		return errors.New("recipe doesn't say how many servings it makes")
+	}
+	if servings <= 0 {
+		return errors.New("servings must be a positive number")
+	}
+	return r.Scale(servings / r.Servings)
+}
+
+// Scale multiplies the ingredient's amount and price by the factor, rewriting Amount to match. The
+// recipe's unit is kept unless the new amount doesn't read well in it, eg. 48 tsp becomes 1 cup,
+// but 5 Tbsp stays as it is. It returns false if the ingredient was left alone, eg. because it is
+// used "to taste" or has no amount.
+func (i *Ingredient) Scale(factor float64) bool {
+	if !i.hasQuantity() || !i.Quantity.Scalable() {
+		return false
+	}
+	q := i.Quantity.Mul(factor)
+	if from, ok := units.Lookup(i.Unit); ok && from.Dimension != units.Count {
+		to := from
+		if factor != 1 && !units.Readable(q.Value, from) {
+			_, to = units.Rescale(q.Value, from)
+		}
+		i.setQuantity(q, from, to)
+	} else {
+		// counts and unknown units (eg. "2 large eggs", "1 can") can only be rounded
+		i.roundQuantity(q, units.Unit{})
+	}
+	i.Cost = i.Cost.mul(factor)
+	return true
+}
+
+// Rescale moves the ingredient's amount to the most readable unit of its system, eg. 1500 g
+// becomes 1.5 kg. It returns false if the ingredient was left alone, eg. because it is a count.
+func (i *Ingredient) Rescale() bool {
+	if !i.hasQuantity() || !i.Quantity.Scalable() {
+		return false
+	}
+	from, ok := units.Lookup(i.Unit)
+	if !ok || from.Dimension == units.Count {
+		return false
+	}
+	_, to := units.Rescale(i.Quantity.Value, from)
+	i.setQuantity(*i.Quantity, from, to)
+	return true
+}
+
+// mul returns a copy of the amount multiplied by the factor, rounded to the nearest cent.
+func (m *Money) mul(factor float64) *Money {
+	if m == nil {
+		return nil
+	}
+	return &Money{Cents: int64(math.Round(float64(m.Cents) * factor)), Currency: m.Currency}
+}
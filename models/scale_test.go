@@ -0,0 +1,109 @@
+package models
+
+import "testing"
+
+func TestScaleIngredient(t *testing.T) {
+	tests := []struct {
+		in     Ingredient
+		factor float64
+		amount string
+		unit   string
+	}{
+		{Ingredient{Amount: "3", Unit: "lbs.", Name: "russet potatoes"}, 2, "6", "lbs."},
+		{Ingredient{Amount: "16", Unit: "tsp", Name: "sugar"}, 3, "1", "cup"},
+		{Ingredient{Amount: "1", Unit: "tsp", Name: "cumin"}, 3, "1", "Tbsp"},
+		{Ingredient{Amount: "5", Unit: "Tbsp", Name: "butter"}, 1, "5", "Tbsp"},
+		{Ingredient{Amount: "5", Unit: "Tbsp", Name: "butter"}, 2, "5/8", "cup"},
+		{Ingredient{Amount: "1/2", Unit: "cup", Name: "milk"}, 0.25, "2", "Tbsp"},
+		{Ingredient{Amount: "1/4", Unit: "tsp", Name: "pepper"}, 1.0 / 3, "1/8", "tsp"},
+		{Ingredient{Amount: "1 1/2", Unit: "cups", Name: "broth"}, 1.5, "2 1/4", "cups"},
+		{Ingredient{Amount: "2-3", Unit: "Tbsp", Name: "butter"}, 2, "1/4-3/8", "cup"},
+		{Ingredient{Amount: "500", Unit: "g", Name: "pasta"}, 1.5, "750", "g"},
+		{Ingredient{Amount: "3", Unit: "cloves", Name: "garlic"}, 0.5, "1 1/2", "cloves"},
+		{Ingredient{Amount: "1", Name: "onion"}, 0.3, "1/3", ""},
+		{Ingredient{Amount: "24", Name: "tortillas"}, 0.53, "12 1/2", ""},
+		{Ingredient{Amount: "to taste", Name: "salt"}, 2, "to taste", ""},
+		{Ingredient{Name: "cooking spray"}, 2, "", ""},
+	}
+
+	for _, test := range tests {
+		i := test.in
+		i.Scale(test.factor)
+		if i.Amount != test.amount || i.Unit != test.unit {
+			t.Errorf("Scale(%s %s %s, %v) = %s %s, want %s %s", test.in.Amount, test.in.Unit,
+				test.in.Name, test.factor, i.Amount, i.Unit, test.amount, test.unit)
+		}
+	}
+}
+
+func TestScaleToServings(t *testing.T) {
+	rcp := Recipe{
+		Ingredients: IngredientGroups{{Ingredients: []Ingredient{
+			{Amount: "3", Unit: "lbs.", Name: "russet potatoes", Cost: &Money{180, "USD"}},
+			{Amount: "1/4", Unit: "tsp", Name: "pepper", Cost: &Money{5, "USD"}},
+			{Name: "salt", Notes: "to taste", Cost: &Money{3, "USD"}},
+		}}},
+		Servings: 6,
+		Cost: &RecipeCost{
+			Recipe:      &Money{188, "USD"},
+			Serving:     &Money{31, "USD"},
+			Ingredients: &Money{188, "USD"},
+		},
+	}
+	if err := rcp.ScaleToServings(9); err != nil {
+		t.Fatal(err)
+	}
+
+	if rcp.Servings != 9 {
+		t.Errorf("Servings = %v, want 9", rcp.Servings)
+	}
+	ingredients := rcp.AllIngredients()
+	if got := ingredients[0].Amount; got != "4 1/2" {
+		t.Errorf("potatoes amount = %q, want %q", got, "4 1/2")
+	}
+	if got := ingredients[0].Cost.Cents; got != 270 {
+		t.Errorf("potatoes cost = %d, want 270", got)
+	}
+	if got := ingredients[2].Cost.Cents; got != 3 {
+		t.Errorf("salt cost = %d, want 3 (to taste isn't scaled)", got)
+	}
+	if got := rcp.Cost.Recipe.Cents; got != 282 {
+		t.Errorf("recipe cost = %d, want 282", got)
+	}
+	if got := rcp.Cost.Ingredients.Cents; got != 281 {
+		t.Errorf("ingredients cost = %d, want 281", got)
+	}
+	if got := rcp.Cost.Serving.Cents; got != 31 {
+		t.Errorf("serving cost = %d, want 31", got)
+	}
+
+	if err := (&Recipe{}).ScaleToServings(4); err == nil {
+		t.Error("ScaleToServings on a recipe without servings should fail")
+	}
+}
+
+func TestScaleCostMismatch(t *testing.T) {
+	rcp := Recipe{
+		Ingredients: IngredientGroups{{Ingredients: []Ingredient{
+			{Amount: "3", Unit: "lbs.", Name: "russet potatoes", Cost: &Money{180, "USD"}},
+			{Amount: "1/4", Unit: "tsp", Name: "pepper", Cost: &Money{5, "USD"}},
+		}}},
+		Servings: 6,
+		// the flag was set before the stated cost was corrected
+		Cost: &RecipeCost{Recipe: &Money{185, "USD"}, Mismatch: true},
+	}
+	if err := rcp.Scale(2); err != nil {
+		t.Fatal(err)
+	}
+	if rcp.Cost.Recipe.Cents != 370 || rcp.Cost.Ingredients.Cents != 370 || rcp.Cost.Mismatch {
+		t.Errorf("cost = %+v, want 370 without a mismatch", rcp.Cost)
+	}
+
+	rcp.AllIngredients()[0].Cost.Cents = 500
+	if err := rcp.Scale(1); err != nil {
+		t.Fatal(err)
+	}
+	if !rcp.Cost.Mismatch {
+		t.Errorf("cost = %+v, want a mismatch", rcp.Cost)
+	}
+}
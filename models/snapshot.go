@@ -0,0 +1,39 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Snapshot is the raw page that a recipe was parsed from. It is kept so the recipe can be parsed
+// again (eg. after the extractor improves) without downloading the page.
+type Snapshot struct {
+	URL       string      `json:"url"`
+	FetchedAt time.Time   `json:"fetchedAt"`
+	Status    int         `json:"status"`
+	Header    http.Header `json:"header,omitempty"`
+	// SHA256 is the hex-encoded SHA-256 hash of the body.
+	SHA256 string `json:"sha256"`
+	Body   []byte `json:"-"`
+}
+
+// NewSnapshot makes a snapshot of a page, hashing its body.
+func NewSnapshot(url string, fetchedAt time.Time, status int, header http.Header, body []byte) *Snapshot {
+	return &Snapshot{URL: url, FetchedAt: fetchedAt, Status: status, Header: header, SHA256: hashBody(body), Body: body}
+}
+
+// Verify checks the body against its hash, in case the snapshot was damaged.
+func (s *Snapshot) Verify() error {
+	if hashBody(s.Body) != s.SHA256 {
+		return errors.New("snapshot of " + s.URL + " doesn't match its hash")
+	}
+	return nil
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package models
+
+import "github.com/ejacobg/recipe-parser/units"
+
+// ConvertUnits converts every ingredient with a known mass or volume unit to the given system.
+func (r *Recipe) ConvertUnits(system units.System) {
+	for g := range r.Ingredients {
+		for i := range r.Ingredients[g].Ingredients {
+			r.Ingredients[g].Ingredients[i].ConvertUnits(system)
+		}
+	}
+}
+
+// ConvertUnits converts the ingredient's amount and unit to the given system, rewriting Amount and
+// Unit to match. US amounts are written as they are (eg. 500 g is 1.1 lb.) rather than rounded to a
+// kitchen fraction, so that converting doesn't change how much is used; metric amounts are rounded
+// to a sensible number of digits. It returns false if the ingredient was left alone, eg. because
+// it is a count or has no amount.
+func (i *Ingredient) ConvertUnits(system units.System) bool {
+	if !i.hasQuantity() {
+		return false
+	}
+	from, ok := units.Lookup(i.Unit)
+	if !ok || from.Dimension == units.Count || !i.Quantity.Scalable() {
+		return false
+	}
+	_, to := units.Best(i.Quantity.Value, from, system)
+	if to == from {
+		// already in the system, so the recipe's own amount is kept
+		return false
+	}
+	q := i.convert(*i.Quantity, from, to)
+	i.Amount = convertedAmount(q.Value, to)
+	if q.IsRange() {
+		i.Amount += "-" + convertedAmount(q.Max, to)
+	}
+	i.Quantity = &q
+	return true
+}
+
+func convertedAmount(v float64, u units.Unit) string {
+	if u.System == units.Metric {
+		return units.Format(v, u)
+	}
+	return units.FormatAmount(v)
+}
+
+// hasQuantity parses the amount if needed, eg. for recipes saved before quantities were parsed.
+// It reports whether the ingredient has a quantity.
+func (i *Ingredient) hasQuantity() bool {
+	if i.Quantity == nil {
+		if err := i.ParseAmount(); err != nil {
+			return false
+		}
+	}
+	return i.Quantity != nil
+}
+
+// setQuantity converts q from unit `from` to unit `to` and stores it, rewriting Amount and Unit to
+// match. The values are rounded to something measurable (see units.Round).
+func (i *Ingredient) setQuantity(q Quantity, from, to units.Unit) {
+	i.roundQuantity(i.convert(q, from, to), to)
+}
+
+// convert returns q converted from unit `from` to unit `to`, rewriting Unit to match.
+func (i *Ingredient) convert(q Quantity, from, to units.Unit) Quantity {
+	if q.IsRange() {
+		q.Max, _ = units.Convert(q.Max, from, to)
+	}
+	q.Value, _ = units.Convert(q.Value, from, to)
+	if to != from {
+		// keep the recipe's own spelling (eg. "cups") if the unit didn't change
+		i.Unit = to.Symbol
+	}
+	return q
+}
+
+// roundQuantity rounds q for the given unit and stores it, rewriting Amount to match.
+func (i *Ingredient) roundQuantity(q Quantity, u units.Unit) {
+	q.Value = units.Round(q.Value, u)
+	i.Amount = units.Format(q.Value, u)
+	if q.IsRange() {
+		q.Max = units.Round(q.Max, u)
+		i.Amount += "-" + units.Format(q.Max, u)
+	}
+	i.Quantity = &q
+}
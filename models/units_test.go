@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+func TestConvertUnits(t *testing.T) {
+	tests := []struct {
+		in     Ingredient
+		system units.System
+		amount string
+		unit   string
+	}{
+		{Ingredient{Amount: "3", Unit: "lbs.", Name: "russet potatoes"}, units.Metric, "1.4", "kg"},
+		{Ingredient{Amount: "1.5", Unit: "cups", Name: "chicken broth"}, units.Metric, "355", "ml"},
+		{Ingredient{Amount: "1/4", Unit: "tsp", Name: "pepper"}, units.Metric, "1.2", "ml"},
+		{Ingredient{Amount: "2-3", Unit: "Tbsp", Name: "butter"}, units.Metric, "30-44", "ml"},
+		{Ingredient{Amount: "500", Unit: "g", Name: "pasta"}, units.US, "1.1", "lb."},
+		{Ingredient{Amount: "2", Unit: "cloves", Name: "garlic"}, units.Metric, "2", "cloves"},
+		// already US units, so the recipe's own amounts are kept
+		{Ingredient{Amount: "5", Unit: "Tbsp", Name: "butter"}, units.US, "5", "Tbsp"},
+		{Ingredient{Amount: "0.3", Unit: "cups", Name: "milk"}, units.US, "0.3", "cups"},
+		{Ingredient{Unit: "tsp", Name: "salt", Notes: "to taste"}, units.Metric, "", "tsp"},
+	}
+
+	for _, test := range tests {
+		i := test.in
+		i.ConvertUnits(test.system)
+		if i.Amount != test.amount || i.Unit != test.unit {
+			t.Errorf("ConvertUnits(%s %s %s) = %s %s, want %s %s", test.in.Amount, test.in.Unit,
+				test.in.Name, i.Amount, i.Unit, test.amount, test.unit)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package pantry finds the recipes that can be cooked with the ingredients on hand.
+package pantry
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/models"
+)
+
+// Staples are ingredients that every kitchen is assumed to have. They are left out when working
+// out how much of a recipe is covered, given as normalized names (see models.NormalizeName).
+var Staples = map[string]bool{
+	"salt":            true,
+	"pepper":          true,
+	"black pepper":    true,
+	"salt and pepper": true,
+	"water":           true,
+	"ice":             true,
+	"cooking spray":   true,
+	"non-stick spray": true,
+}
+
+// IsStaple reports whether the ingredient name is one of the Staples.
+func IsStaple(name string) bool {
+	return Staples[models.NormalizeName(name)]
+}
+
+// Pantry is a list of ingredients on hand.
+type Pantry struct {
+	items [][]string // the words of each normalized name
+}
+
+// New returns a pantry holding the named ingredients, eg. "potatoes", "Butter".
+func New(items []string) *Pantry {
+	p := &Pantry{}
+	for _, item := range items {
+		if words := strings.Fields(models.NormalizeName(item)); len(words) > 0 {
+			p.items = append(p.items, words)
+		}
+	}
+	return p
+}
+
+// Has reports whether the pantry holds the ingredient. An item covers an ingredient if every word of
+// the item appears in the ingredient's name, so "potatoes" covers "russet potatoes", but "peanut
+// butter" doesn't cover "butter".
+func (p *Pantry) Has(name string) bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(models.NormalizeName(name)) {
+		words[word] = true
+	}
+	for _, item := range p.items {
+		found := true
+		for _, word := range item {
+			if !words[word] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// Match is how well a pantry covers a recipe.
+type Match struct {
+	Recipe *models.Recipe `json:"recipe"`
+	// Coverage is the fraction of the recipe's ingredients that are in the pantry, from 0 to 1.
+	// Staples aren't counted.
+	Coverage float64 `json:"coverage"`
+	// Have and Missing list the names of the recipe's ingredients (as written) that are and aren't
+	// in the pantry.
+	Have    []string `json:"have"`
+	Missing []string `json:"missing"`
+}
+
+// Match works out how well the pantry covers the recipe. A recipe with nothing but staples is
+// fully covered.
+func (p *Pantry) Match(rcp *models.Recipe) Match {
+	m := Match{Recipe: rcp, Have: []string{}, Missing: []string{}}
+	seen := make(map[string]bool)
+	for _, i := range rcp.AllIngredients() {
+		name := strings.TrimSpace(i.Name)
+		normalized := models.NormalizeName(name)
+		// the same ingredient may be listed more than once, eg. butter for the sauce and the topping
+		if normalized == "" || Staples[normalized] || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		if p.Has(name) {
+			m.Have = append(m.Have, name)
+		} else {
+			m.Missing = append(m.Missing, name)
+		}
+	}
+	m.Coverage = 1
+	if total := len(m.Have) + len(m.Missing); total > 0 {
+		m.Coverage = float64(len(m.Have)) / float64(total)
+	}
+	return m
+}
+
+// Rank matches every recipe against the pantry, and returns the ones with at least minCoverage,
+// best covered first. Recipes with the same coverage are ordered by how few ingredients are
+// missing, then by name.
+func (p *Pantry) Rank(recipes []*models.Recipe, minCoverage float64) []Match {
+	matches := []Match{}
+	for _, rcp := range recipes {
+		if m := p.Match(rcp); m.Coverage >= minCoverage {
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Coverage != b.Coverage {
+			return a.Coverage > b.Coverage
+		}
+		if len(a.Missing) != len(b.Missing) {
+			return len(a.Missing) < len(b.Missing)
+		}
+		return a.Recipe.Name < b.Recipe.Name
+	})
+	return matches
+}
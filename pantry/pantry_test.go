@@ -0,0 +1,70 @@
+package pantry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHas(t *testing.T) {
+	p := New([]string{"Potatoes", "peanut butter", "fresh garlic"})
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"russet potatoes", true},
+		{"potato", true},
+		{"garlic, minced", true},
+		{"butter", false},
+		{"creamy peanut butter", true},
+		{"onion", false},
+	}
+	for _, test := range tests {
+		if got := p.Has(test.name); got != test.want {
+			t.Errorf("Has(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	// copies of the recipes in ../database, so that saving new recipes there doesn't change the ranking
+	files, _ := filepath.Glob("./test-data/*.json")
+	if len(files) == 0 {
+		t.Fatal("no recipes in test-data")
+	}
+	var recipes []*models.Recipe
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rcp := &models.Recipe{}
+		if err = json.Unmarshal(data, rcp); err != nil {
+			t.Fatal(err)
+		}
+		recipes = append(recipes, rcp)
+	}
+
+	matches := New([]string{"potatoes", "garlic", "butter", "milk"}).Rank(recipes, 0.5)
+	var got []string
+	for _, m := range matches {
+		got = append(got, m.Recipe.Name)
+	}
+	want := []string{"Slow Cooker Mashed Potatoes", "Garlic Herb Mashed Potatoes"}
+	if !cmp.Equal(got, want) {
+		t.Fatalf("Rank = %v, want %v", got, want)
+	}
+
+	// salt and pepper aren't counted
+	m := matches[0]
+	if m.Coverage != 4.0/6 {
+		t.Errorf("coverage = %v, want 4/6", m.Coverage)
+	}
+	if want := []string{"chicken broth", "cream cheese"}; !cmp.Equal(m.Missing, want) {
+		t.Errorf("missing = %v, want %v", m.Missing, want)
+	}
+}
@@ -3,6 +3,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
@@ -13,31 +14,37 @@ import (
 // FindRecipeCard returns the node representing the enclosing <div> of the recipe.
 // All the relevant recipe information is rooted at this node.
 func FindRecipeCard(node *html.Node) *html.Node {
-	return GetElementWithClass(node, atom.Div, "wprm-recipe-container")
+	return QuerySelector(node, "div.wprm-recipe-container")
+}
+
+// FindJSONLDScripts returns every <script type="application/ld+json"> element in the document.
+// Sites put schema.org metadata (including recipes) in these blocks.
+func FindJSONLDScripts(node *html.Node) []*html.Node {
+	return QuerySelectorAll(node, `script[type="application/ld+json"]`)
 }
 
 // FindIngredientLists returns all nodes representing a list of ingredients.
 // Some recipes group their ingredients under different headers (eg. "Sauce", "Garnishes"),
 // each with their own list.
 func FindIngredientLists(node *html.Node) []*html.Node {
-	matcher := func(node *html.Node) (keep, exit bool) {
-		if node.Type == html.ElementNode && node.DataAtom == atom.Ul {
-			for _, a := range node.Attr {
-				if a.Key == "class" && a.Val == "wprm-recipe-ingredients" {
-					keep = true
-					exit = true // No nested ingredients lists
-				}
-			}
-		}
-		return
+	return QuerySelectorAll(node, "ul.wprm-recipe-ingredients")
+}
+
+// FindIngredientGroupName returns the header node naming the group that the given ingredient list
+// belongs to, or nil if the list isn't under a header.
+// WPRM wraps each group in a <div> containing the header followed by the list.
+func FindIngredientGroupName(list *html.Node) *html.Node {
+	group := list.Parent
+	if group == nil || !HasClass(group, "wprm-recipe-ingredient-group") {
+		return nil
 	}
-	return FindNodes(node, matcher)
+	return QuerySelector(group, ".wprm-recipe-ingredient-group > .wprm-recipe-group-name")
 }
 
 // FindInstructionsList returns the node representing the list of recipe instructions.
 // This implementation currently assumes that there is only 1 master list of instructions.
 func FindInstructionsList(node *html.Node) *html.Node {
-	return GetElementWithClass(node, atom.Ul, "wprm-recipe-instructions")
+	return QuerySelector(node, "ul.wprm-recipe-instructions")
 }
 
 // GetElementWithClass returns the first element underneath and including `node` that has the given
@@ -56,7 +63,37 @@ func GetElementWithClass(node *html.Node, tagname atom.Atom, class string) *html
 	return FindNode(node, matcher)
 }
 
-// GetTextNode returns the first text node under the given node.
+// HasClass reports whether the element has the given class among its space-separated classes.
+// Unlike GetElementWithClass, the order of the other classes doesn't matter.
+func HasClass(node *html.Node, class string) bool {
+	for _, a := range node.Attr {
+		if a.Key == "class" {
+			for _, c := range strings.Fields(a.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// GetAttribute returns the value of the node's attribute, or "" if it isn't set.
+func GetAttribute(node *html.Node, key string) string {
+	if node == nil {
+		return ""
+	}
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// GetTextNode returns the first text node under the given node. That is only the start of the
+// text if the node contains other elements (eg. "Add the" out of "Add the <a>garlic</a>"), so use
+// GetText for an element's text.
 func GetTextNode(node *html.Node) *html.Node {
 	matcher := func(node *html.Node) bool {
 		return node.Type == html.TextNode
@@ -65,7 +102,11 @@ func GetTextNode(node *html.Node) *html.Node {
 }
 
 // FindNode will return the first node that the matcher function accepts.
+// Searching a nil node finds nothing, so lookups can be chained without checking each one.
 func FindNode(node *html.Node, matcher func(node *html.Node) bool) *html.Node {
+	if node == nil {
+		return nil
+	}
 	if matcher(node) {
 		return node
 	}
@@ -83,6 +124,9 @@ func FindNode(node *html.Node, matcher func(node *html.Node) bool) *html.Node {
 // current node.
 // See TraverseNode from https://gist.github.com/Xeoncross/8bbb84bc4bf540bd907f79ee17c4e1fc
 func FindNodes(node *html.Node, matcher func(node *html.Node) (bool, bool)) (nodes []*html.Node) {
+	if node == nil {
+		return nil
+	}
 	var keep, exit bool
 	var f func(*html.Node)
 	f = func(n *html.Node) {
@@ -0,0 +1,483 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// This is a small subset of CSS selectors, enough to describe where things are on a recipe card.
+// See https://developer.mozilla.org/en-US/docs/Web/CSS/CSS_Selectors
+//
+// Supported:
+//   - type selectors and the universal selector: div, *
+//   - class and ID selectors: .wprm-recipe-name, #recipe
+//   - attribute selectors: [href], [rel=canonical], [class~=x], [class^=x], [class$=x],
+//     [class*=x], [lang|=en]
+//   - pseudo-classes: :nth-child(an+b), :nth-child(odd|even), :first-child, :last-child
+//   - descendant (whitespace) and child (>) combinators
+//   - selector lists separated by commas
+
+// Selector is a compiled selector list. Use Compile or MustCompile to create one.
+type Selector struct {
+	source  string
+	complex []complexSelector
+}
+
+// A complex selector is a chain of compound selectors joined by combinators, stored right to left
+// since that is the order they are matched in.
+type complexSelector []step
+
+type step struct {
+	compound
+	// combinator joins this compound to the next one in the slice, ie. the one to its left.
+	combinator byte
+}
+
+// A compound selector is a list of conditions that must all hold for a single element.
+type compound struct {
+	tag     string // empty or "*" matches any element
+	classes []string
+	id      string
+	attrs   []attrSelector
+	nth     []nthChild
+}
+
+type attrSelector struct {
+	key, op, val string
+}
+
+// nthChild matches elements at position a*n + b for some n >= 0. Positions start at 1.
+// If last is set, positions are counted from the end instead.
+type nthChild struct {
+	a, b int
+	last bool
+}
+
+// Compile parses a selector, returning an error if it isn't valid.
+func Compile(selector string) (*Selector, error) {
+	p := &selectorParser{src: selector}
+	list, err := p.parseList()
+	if err != nil {
+		return nil, fmt.Errorf("parser: invalid selector %q: %w", selector, err)
+	}
+	return &Selector{selector, list}, nil
+}
+
+// cache keeps the selectors used by QuerySelector and QuerySelectorAll, which are written into the
+// code, so there are only ever a few of them. Selectors from anywhere else should be compiled once
+// with Compile rather than cached, so that the cache can't grow without limit.
+var cache sync.Map // map[string]*Selector
+
+// cached returns the compiled selector from the cache, compiling it the first time.
+func cached(selector string) *Selector {
+	if s, ok := cache.Load(selector); ok {
+		return s.(*Selector)
+	}
+	s := MustCompile(selector)
+	cache.Store(selector, s)
+	return s
+}
+
+// MustCompile is like Compile but panics if the selector is invalid.
+// It is meant for selectors written into the code, which should always be valid.
+func MustCompile(selector string) *Selector {
+	s, err := Compile(selector)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the source text of the selector.
+func (s *Selector) String() string {
+	return s.source
+}
+
+// QuerySelector returns the first element underneath and including `node` that matches the
+// selector, or nil if there is none. It panics if the selector is invalid, see MustCompile.
+func QuerySelector(node *html.Node, selector string) *html.Node {
+	return cached(selector).First(node)
+}
+
+// QuerySelectorAll returns every element underneath and including `node` that matches the
+// selector, in document order. It panics if the selector is invalid, see MustCompile.
+func QuerySelectorAll(node *html.Node, selector string) []*html.Node {
+	return cached(selector).All(node)
+}
+
+// First returns the first element underneath and including `node` that matches.
+func (s *Selector) First(node *html.Node) *html.Node {
+	return FindNode(node, s.Match)
+}
+
+// All returns every element underneath and including `node` that matches.
+func (s *Selector) All(node *html.Node) []*html.Node {
+	matcher := func(n *html.Node) (bool, bool) {
+		return s.Match(n), false
+	}
+	return FindNodes(node, matcher)
+}
+
+// Match reports whether the node matches any selector in the list.
+// Combinators may look at ancestors outside of the node being searched, like the DOM does.
+func (s *Selector) Match(node *html.Node) bool {
+	if node == nil || node.Type != html.ElementNode {
+		return false
+	}
+	for _, c := range s.complex {
+		if c.match(node, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c complexSelector) match(node *html.Node, i int) bool {
+	if !c[i].compound.match(node) {
+		return false
+	}
+	if i == len(c)-1 {
+		return true
+	}
+	switch c[i].combinator {
+	case '>':
+		parent := node.Parent
+		return parent != nil && parent.Type == html.ElementNode && c.match(parent, i+1)
+	default:
+		for a := node.Parent; a != nil && a.Type == html.ElementNode; a = a.Parent {
+			if c.match(a, i+1) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c *compound) match(node *html.Node) bool {
+	if c.tag != "" && c.tag != "*" && c.tag != node.Data {
+		return false
+	}
+	if c.id != "" && GetAttribute(node, "id") != c.id {
+		return false
+	}
+	for _, class := range c.classes {
+		if !HasClass(node, class) {
+			return false
+		}
+	}
+	for _, a := range c.attrs {
+		if !a.match(node) {
+			return false
+		}
+	}
+	for _, n := range c.nth {
+		if !n.match(node) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a attrSelector) match(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		if attr.Key != a.key {
+			continue
+		}
+		v := attr.Val
+		switch a.op {
+		case "":
+			return true
+		case "=":
+			return v == a.val
+		case "~=":
+			for _, word := range strings.Fields(v) {
+				if word == a.val {
+					return true
+				}
+			}
+			return false
+		case "^=":
+			return a.val != "" && strings.HasPrefix(v, a.val)
+		case "$=":
+			return a.val != "" && strings.HasSuffix(v, a.val)
+		case "*=":
+			return a.val != "" && strings.Contains(v, a.val)
+		case "|=":
+			return v == a.val || strings.HasPrefix(v, a.val+"-")
+		}
+	}
+	return false
+}
+
+func (n nthChild) match(node *html.Node) bool {
+	pos := 1
+	sibling := func(c *html.Node) *html.Node { return c.PrevSibling }
+	if n.last {
+		sibling = func(c *html.Node) *html.Node { return c.NextSibling }
+	}
+	for c := sibling(node); c != nil; c = sibling(c) {
+		if c.Type == html.ElementNode {
+			pos++
+		}
+	}
+	if n.a == 0 {
+		return pos == n.b
+	}
+	// pos = a*n + b must have a solution with n >= 0
+	diff := pos - n.b
+	return diff%n.a == 0 && diff/n.a >= 0
+}
+
+type selectorParser struct {
+	src string
+	pos int
+}
+
+func (p *selectorParser) parseList() (list []complexSelector, err error) {
+	for {
+		c, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+		p.skipSpace()
+		if p.pos == len(p.src) {
+			return list, nil
+		}
+		if p.src[p.pos] != ',' {
+			return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos], p.pos)
+		}
+		p.pos++
+	}
+}
+
+func (p *selectorParser) parseComplex() (complexSelector, error) {
+	var steps []step
+	p.skipSpace()
+	for {
+		c, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step{compound: c})
+
+		hadSpace := p.skipSpace()
+		if p.pos == len(p.src) || p.src[p.pos] == ',' {
+			break
+		}
+		combinator := byte(' ')
+		if p.src[p.pos] == '>' {
+			combinator = '>'
+			p.pos++
+			p.skipSpace()
+		} else if !hadSpace {
+			return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos], p.pos)
+		}
+		steps[len(steps)-1].combinator = combinator
+	}
+
+	// Reverse so that matching starts with the rightmost compound. Each combinator is moved along
+	// with it so that it still joins the same two compounds.
+	c := make(complexSelector, len(steps))
+	for i, s := range steps {
+		j := len(steps) - 1 - i
+		c[j].compound = s.compound
+		if i > 0 {
+			c[j].combinator = steps[i-1].combinator
+		}
+	}
+	return c, nil
+}
+
+func (p *selectorParser) parseCompound() (c compound, err error) {
+	start := p.pos
+	if p.peek() == '*' {
+		c.tag = "*"
+		p.pos++
+	} else if isIdentStart(p.peek()) {
+		c.tag = strings.ToLower(p.ident())
+	}
+
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '.':
+			p.pos++
+			class := p.ident()
+			if class == "" {
+				return c, errors.New("expected class name after '.'")
+			}
+			c.classes = append(c.classes, class)
+		case '#':
+			p.pos++
+			c.id = p.ident()
+			if c.id == "" {
+				return c, errors.New("expected id after '#'")
+			}
+		case '[':
+			a, err := p.parseAttr()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, a)
+		case ':':
+			n, err := p.parsePseudo()
+			if err != nil {
+				return c, err
+			}
+			c.nth = append(c.nth, n)
+		default:
+			if p.pos == start {
+				return c, fmt.Errorf("expected selector at offset %d", p.pos)
+			}
+			return c, nil
+		}
+	}
+	if p.pos == start {
+		return c, errors.New("empty selector")
+	}
+	return c, nil
+}
+
+func (p *selectorParser) parseAttr() (a attrSelector, err error) {
+	p.pos++ // [
+	p.skipSpace()
+	a.key = strings.ToLower(p.ident())
+	if a.key == "" {
+		return a, fmt.Errorf("expected attribute name at offset %d", p.pos)
+	}
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return a, nil
+	}
+
+	for _, op := range []string{"=", "~=", "^=", "$=", "*=", "|="} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			a.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if a.op == "" {
+		return a, fmt.Errorf("expected attribute operator at offset %d", p.pos)
+	}
+	p.skipSpace()
+
+	switch quote := p.peek(); quote {
+	case '"', '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], quote)
+		if end < 0 {
+			return a, errors.New("unterminated string")
+		}
+		a.val = p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	default:
+		a.val = p.ident()
+		if a.val == "" {
+			return a, fmt.Errorf("expected attribute value at offset %d", p.pos)
+		}
+	}
+
+	p.skipSpace()
+	if p.peek() != ']' {
+		return a, fmt.Errorf("expected ']' at offset %d", p.pos)
+	}
+	p.pos++
+	return a, nil
+}
+
+func (p *selectorParser) parsePseudo() (n nthChild, err error) {
+	p.pos++ // :
+	name := strings.ToLower(p.ident())
+	switch name {
+	case "first-child":
+		return nthChild{b: 1}, nil
+	case "last-child":
+		return nthChild{b: 1, last: true}, nil
+	case "nth-child", "nth-last-child":
+		n.last = name == "nth-last-child"
+	default:
+		return n, fmt.Errorf("unsupported pseudo-class %q", name)
+	}
+
+	if p.peek() != '(' {
+		return n, fmt.Errorf("expected '(' after :%s", name)
+	}
+	end := strings.IndexByte(p.src[p.pos:], ')')
+	if end < 0 {
+		return n, fmt.Errorf("expected ')' after :%s", name)
+	}
+	arg := p.src[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+	n.a, n.b, err = parseNth(arg)
+	return n, err
+}
+
+// parseNth parses the an+b notation, eg. "2n+1", "-n+3", "odd", "4".
+func parseNth(arg string) (a, b int, err error) {
+	arg = strings.ToLower(strings.ReplaceAll(arg, " ", ""))
+	switch arg {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+
+	i := strings.IndexByte(arg, 'n')
+	if i < 0 {
+		b, err = strconv.Atoi(arg)
+		return 0, b, err
+	}
+	switch coef := arg[:i]; coef {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		if a, err = strconv.Atoi(coef); err != nil {
+			return 0, 0, err
+		}
+	}
+	if rest := arg[i+1:]; rest != "" {
+		if b, err = strconv.Atoi(rest); err != nil {
+			return 0, 0, err
+		}
+	}
+	return a, b, nil
+}
+
+func (p *selectorParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte(" \t\n\r\f", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '-' || c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const page = `<html><head><link rel="canonical" href="https://www.budgetbytes.com/x/"><link rel="stylesheet" href="a.css"></head>
+<body><div id="main" class="wprm-recipe-container wprm-recipe">
+<h2 class="wprm-recipe-name wprm-block-text-bold">Name</h2>
+<img class="perfmatters-lazy attachment-268x268 size-268x268" data-pin-media="img.jpg">
+<ul class="wprm-recipe-ingredients"><li id="a">a</li><li id="b">b</li><li id="c">c</li><li id="d">d</li></ul>
+<div><ul class="other"><li id="e">e</li></ul></div>
+</div></body></html>`
+
+func ids(nodes []*html.Node) string {
+	var list []string
+	for _, n := range nodes {
+		v := GetAttribute(n, "id")
+		if v == "" {
+			v = n.Data
+		}
+		list = append(list, v)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestQuerySelectorAll(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector, want string
+	}{
+		{"li", "a,b,c,d,e"},
+		{"#main", "main"},
+		{"div.wprm-recipe", "main"},
+		{".wprm-recipe.wprm-recipe-container", "main"},
+		{"img.attachment-268x268.perfmatters-lazy", "img"},
+		{"link[rel=canonical]", "link"},
+		{`link[rel="stylesheet"]`, "link"},
+		{"[class~=wprm-block-text-bold]", "h2"},
+		{"[class^=wprm-recipe-n]", "h2"},
+		{"[class*=268x268]", "img"},
+		{"[class$=size-268x268]", "img"},
+		{"ul.wprm-recipe-ingredients li", "a,b,c,d"},
+		{"#main > ul > li", "a,b,c,d"},
+		{"#main > li", ""},
+		{"#main li", "a,b,c,d,e"},
+		{"ul li:nth-child(2)", "b"},
+		{"ul.wprm-recipe-ingredients li:nth-child(odd)", "a,c"},
+		{"ul.wprm-recipe-ingredients li:nth-child(even)", "b,d"},
+		{"ul.wprm-recipe-ingredients li:nth-child(-n+2)", "a,b"},
+		{"ul.wprm-recipe-ingredients li:nth-child(3n+1)", "a,d"},
+		{"li:first-child", "a,e"},
+		{"li:last-child", "d,e"},
+		{"h2, img", "h2,img"},
+		{"* > ul.other > li", "e"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selector, func(t *testing.T) {
+			got := ids(QuerySelectorAll(doc, test.selector))
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQuerySelector(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := QuerySelector(doc, "li:nth-child(3)"); got == nil || GetAttribute(got, "id") != "c" {
+		t.Errorf("got %v, want li#c", got)
+	}
+	if got := QuerySelector(doc, "table"); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"div >",
+		".",
+		"#",
+		"[href",
+		"[href=]",
+		"[href~~x]",
+		"li:hover",
+		"li:nth-child(x)",
+		`a[title="unterminated]`,
+		"div,",
+	}
+
+	for _, test := range tests {
+		if _, err := Compile(test); err == nil {
+			t.Errorf("Compile(%q) should fail", test)
+		}
+	}
+}
+
+// FuzzSelector compiles mutated selectors and runs them over mutated pages. Invalid selectors
+// should be rejected rather than panic, and First and All should agree with Match. Compile doesn't
+// cache, so the fuzzer's selectors don't pile up in memory.
+func FuzzSelector(f *testing.F) {
+	for _, selector := range []string{
+		"li", "#main", ".wprm-recipe.wprm-recipe-container", "link[rel=canonical]", `link[rel="stylesheet"]`,
+		"[class~=wprm-block-text-bold]", "[class^=wprm-recipe-n]", "#main > ul > li", "ul li:nth-child(2)",
+		"li:nth-child(-n+2)", "li:nth-child(3n+1)", "li:first-child", "h2, img", "* > ul.other > li",
+		"div >", "[href~~x]", "li:nth-child(x)",
+	} {
+		f.Add(selector, page)
+	}
+	cacheSize := countCache()
+
+	f.Fuzz(func(t *testing.T, selector, page string) {
+		s, err := Compile(selector)
+		if err != nil {
+			return
+		}
+		if s.String() != selector {
+			t.Errorf("String() = %q, want %q", s.String(), selector)
+		}
+		doc, err := html.Parse(strings.NewReader(page))
+		if err != nil {
+			return
+		}
+
+		all := s.All(doc)
+		for _, n := range all {
+			if !s.Match(n) {
+				t.Errorf("All returned %v, which doesn't match", n.Data)
+			}
+		}
+		first := s.First(doc)
+		if len(all) == 0 && first != nil || len(all) > 0 && first != all[0] {
+			t.Errorf("First doesn't return the first node from All")
+		}
+		if n := countCache(); n > cacheSize {
+			t.Errorf("Compile added %d selectors to the cache", n-cacheSize)
+		}
+	})
+}
+
+func countCache() (n int) {
+	cache.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// GetText returns all the text underneath the node, roughly as a browser would show it. The text of
+// every descendant is joined together, and each run of whitespace (including the breaks between
+// block elements) becomes a single space. Unlike GetTextNode, text that is split up by links or
+// <strong> tags isn't cut short. A nil node has no text.
+func GetText(node *html.Node) string {
+	return renderText(node, false)
+}
+
+// GetMarkdown is GetText, except that links, bold and italics are kept as Markdown, eg.
+// `Add the <a href="/garlic/">garlic</a>` becomes "Add the [garlic](/garlic/)".
+// Any other characters that mean something in Markdown are escaped.
+func GetMarkdown(node *html.Node) string {
+	return renderText(node, true)
+}
+
+// blockElements are set apart from the text around them, eg. paragraphs or the items of a nested
+// list, so that their words don't run into each other.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Blockquote: true, atom.Dd: true, atom.Div: true, atom.Dl: true,
+	atom.Dt: true, atom.Figcaption: true, atom.Figure: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Hr: true, atom.Li: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Td: true, atom.Th: true, atom.Tr: true, atom.Ul: true,
+}
+
+// hiddenElements hold text that isn't shown on the page.
+var hiddenElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Template: true, atom.Noscript: true,
+}
+
+// isHidden reports whether the element's text isn't shown on the page. Besides hiddenElements, this
+// is text only meant for screen readers, eg. WPRM's "15<span class="sr-only"> minutes</span>".
+func isHidden(n *html.Node) bool {
+	if hiddenElements[n.DataAtom] {
+		return true
+	}
+	for _, a := range n.Attr {
+		if a.Key == "hidden" {
+			return true
+		}
+	}
+	return HasClass(n, "sr-only") || HasClass(n, "screen-reader-text")
+}
+
+func renderText(node *html.Node, markdown bool) string {
+	if node == nil {
+		return ""
+	}
+	var b strings.Builder
+	writeText(&b, node, markdown)
+	return collapseSpace(b.String())
+}
+
+// writeText writes the text under n, leaving the whitespace to be cleaned up by collapseSpace.
+func writeText(b *strings.Builder, n *html.Node, markdown bool) {
+	switch n.Type {
+	case html.TextNode:
+		if markdown {
+			b.WriteString(markdownEscaper.Replace(n.Data))
+		} else {
+			b.WriteString(n.Data)
+		}
+		return
+	case html.ElementNode:
+		switch {
+		case isHidden(n):
+			return
+		case n.DataAtom == atom.Br:
+			b.WriteByte(' ')
+			return
+		case blockElements[n.DataAtom]:
+			b.WriteByte(' ')
+			defer b.WriteByte(' ')
+		case markdown:
+			if before, after, ok := markdownMarks(n); ok {
+				writeMarked(b, n, before, after)
+				return
+			}
+		}
+	case html.DocumentNode:
+	default:
+		// comments and doctypes
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(b, c, markdown)
+	}
+}
+
+// markdownMarks returns the Markdown that goes around the text of a link or emphasis.
+func markdownMarks(n *html.Node) (before, after string, ok bool) {
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		return "**", "**", true
+	case atom.Em, atom.I:
+		return "*", "*", true
+	case atom.A:
+		href := GetAttribute(n, "href")
+		if href == "" {
+			return "", "", false
+		}
+		return "[", "](" + hrefEscaper.Replace(href) + ")", true
+	}
+	return "", "", false
+}
+
+// writeMarked writes the text of n between the marks. Markdown doesn't allow whitespace just inside
+// the marks (eg. "** garlic**"), so it is moved outside of them.
+func writeMarked(b *strings.Builder, n *html.Node, before, after string) {
+	var inner strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(&inner, c, true)
+	}
+	raw := inner.String()
+	text := collapseSpace(raw)
+	if text == "" {
+		b.WriteString(raw)
+		return
+	}
+	if r, _ := utf8.DecodeRuneInString(raw); unicode.IsSpace(r) {
+		b.WriteByte(' ')
+	}
+	b.WriteString(before + text + after)
+	if r, _ := utf8.DecodeLastRuneInString(raw); unicode.IsSpace(r) {
+		b.WriteByte(' ')
+	}
+}
+
+var (
+	markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "`", "\\`")
+	hrefEscaper     = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+)
+
+// collapseSpace trims the string and replaces each run of whitespace (including non-breaking
+// spaces) with a single space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestGetText(t *testing.T) {
+	tests := []struct {
+		html, text, markdown string
+	}{
+		{"Boil the potatoes.", "Boil the potatoes.", "Boil the potatoes."},
+		{"  Add the\n  <a href=\"/garlic/\">garlic</a> and stir. ", "Add the garlic and stir.", "Add the [garlic](/garlic/) and stir."},
+		{"<strong>Optional:</strong> top with chives", "Optional: top with chives", "**Optional:** top with chives"},
+		{"Stir <em>gently</em>, <i>then</i> <b>serve</b>", "Stir gently, then serve", "Stir *gently*, *then* **serve**"},
+		{"<a href=\"/x/\"><strong>red</strong> onion</a>", "red onion", "[**red** onion](/x/)"},
+		{"Add<strong> salt </strong>and pepper", "Add salt and pepper", "Add **salt** and pepper"},
+		{"<a>no link</a> here", "no link here", "no link here"},
+		{"<a href=\"/a b(1)\">odd</a>", "odd", "[odd](/a%20b%281%29)"},
+		{"1/2 cup (about *2 oz) [divided]", "1/2 cup (about *2 oz) [divided]", `1/2 cup (about \*2 oz) \[divided\]`},
+		{"one<br>two<p>three</p>four", "one two three four", "one two three four"},
+		{"<ul><li>a</li><li>b</li></ul>", "a b", "a b"},
+		{"cook&nbsp;&nbsp;it<!-- comment --><script>var x;</script><style>p {}</style>", "cook it", "cook it"},
+		{`15<span class="sr-only screen-reader-text"> minutes</span> <span aria-hidden="true">mins</span>`, "15 mins", "15 mins"},
+		{`<span hidden>secret</span>shown`, "shown", "shown"},
+		{"<strong> </strong>", "", ""},
+		{"", "", ""},
+	}
+
+	for _, test := range tests {
+		nodes, err := html.ParseFragment(strings.NewReader(test.html), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+		if err != nil {
+			t.Fatal(err)
+		}
+		span := &html.Node{Type: html.ElementNode, Data: "span", DataAtom: atom.Span}
+		for _, n := range nodes {
+			span.AppendChild(n)
+		}
+		if got := GetText(span); got != test.text {
+			t.Errorf("GetText(%q) = %q, want %q", test.html, got, test.text)
+		}
+		if got := GetMarkdown(span); got != test.markdown {
+			t.Errorf("GetMarkdown(%q) = %q, want %q", test.html, got, test.markdown)
+		}
+	}
+
+	if got := GetText(nil); got != "" {
+		t.Errorf("GetText(nil) = %q, want \"\"", got)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	utils "github.com/ejacobg/recipe-parser/api-utils"
+	"github.com/ejacobg/recipe-parser/mealplan"
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/shopping"
+	"github.com/ejacobg/recipe-parser/store"
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+func init() {
+	commands["plan"] = command{
+		usage: "recipe-parser plan [-dbpath dir] list|show|create|add|remove|delete|shop|cost|ics ... (see recipe-parser plan -h)",
+		run:   plan,
+	}
+}
+
+const planUsage = `Usage of recipe-parser plan:
+recipe-parser plan list
+recipe-parser plan show <plan>
+recipe-parser plan create <plan> [name]
+recipe-parser plan add <plan> <YYYY-MM-DD> <breakfast|lunch|snack|dinner> <recipe> [servings]
+recipe-parser plan remove <plan> <YYYY-MM-DD> <breakfast|lunch|snack|dinner>
+recipe-parser plan delete <plan>
+recipe-parser plan shop [-from date] [-to date] [-format text|markdown|json] [-units system] <plan>
+recipe-parser plan cost [-from date] [-to date] [-format json] <plan>
+recipe-parser plan ics [-from date] [-to date] [-o file.ics] <plan>
+Recipes are given by ID, URL or name, and must already be saved.
+`
+
+// plan creates, edits and uses meal plans, which are saved next to the recipes.
+func plan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	dbPath := dbFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), planUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no plan command given")
+	}
+
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close(db)
+	plans, ok := db.(store.PlanStore)
+	if !ok {
+		return errors.New("this store can't save meal plans")
+	}
+
+	ctx := context.TODO()
+	action, args := fs.Arg(0), fs.Args()[1:]
+	switch action {
+	case "list":
+		all, err := plans.ListPlans(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, p := range all {
+			fmt.Fprintf(w, "%s\t%s\t%d meals\n", p.ID, p.Name, len(p.Meals))
+		}
+		return w.Flush()
+
+	case "show":
+		if len(args) != 1 {
+			return errors.New("usage: plan show <plan>")
+		}
+		p, err := plans.GetPlan(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return showPlan(ctx, db, p)
+
+	case "create":
+		if len(args) < 1 {
+			return errors.New("usage: plan create <plan> [name]")
+		}
+		if _, err := plans.GetPlan(ctx, args[0]); err == nil {
+			return errors.New("plan " + args[0] + " already exists")
+		} else if err != store.ErrPlanNotFound {
+			return err
+		}
+		return plans.SavePlan(ctx, &models.Plan{ID: args[0], Name: strings.Join(args[1:], " "), Meals: []models.Meal{}})
+
+	case "add":
+		if len(args) != 4 && len(args) != 5 {
+			return errors.New("usage: plan add <plan> <date> <slot> <recipe> [servings]")
+		}
+		p, err := plans.GetPlan(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		slot, err := models.ParseSlot(args[2])
+		if err != nil {
+			return err
+		}
+		rcp, err := utils.FindRecipe(ctx, db, args[3])
+		if err == store.ErrNotFound {
+			return errors.New("recipe " + args[3] + " isn't saved, save it first with: recipe-parser " + args[3])
+		}
+		if err != nil {
+			return err
+		}
+		meal := models.Meal{Date: args[1], Slot: slot, RecipeID: rcp.ID}
+		if len(args) == 5 {
+			if meal.Servings, err = strconv.ParseFloat(args[4], 64); err != nil || meal.Servings <= 0 {
+				return errors.New("servings must be a positive number")
+			}
+			if rcp.Servings <= 0 {
+				return errors.New(rcp.Name + " doesn't say how many servings it makes, so it can't be scaled")
+			}
+		}
+		p.Set(meal)
+		return plans.SavePlan(ctx, p)
+
+	case "remove":
+		if len(args) != 3 {
+			return errors.New("usage: plan remove <plan> <date> <slot>")
+		}
+		p, err := plans.GetPlan(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		slot, err := models.ParseSlot(args[2])
+		if err != nil {
+			return err
+		}
+		if !p.Remove(args[1], slot) {
+			return errors.New("nothing is planned for " + string(slot) + " on " + args[1])
+		}
+		return plans.SavePlan(ctx, p)
+
+	case "delete":
+		if len(args) != 1 {
+			return errors.New("usage: plan delete <plan>")
+		}
+		return plans.DeletePlan(ctx, args[0])
+
+	case "shop", "cost", "ics":
+		return usePlan(ctx, db, plans, action, args)
+	}
+	fs.Usage()
+	return errors.New("unknown plan command " + action)
+}
+
+// showPlan prints the plan's meals, one per line.
+func showPlan(ctx context.Context, db store.RecipeStore, p *models.Plan) error {
+	fmt.Println(p.ID, p.Name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, m := range p.Meals {
+		name := m.RecipeID
+		if rcp, err := db.GetByID(ctx, m.RecipeID); err == nil {
+			name = rcp.Name
+		}
+		servings := ""
+		if m.Servings > 0 {
+			servings = strconv.FormatFloat(m.Servings, 'f', -1, 64) + " servings"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Date, m.Slot, name, servings)
+	}
+	return w.Flush()
+}
+
+// usePlan runs the plan commands that need the plan's recipes: shop, cost and ics.
+func usePlan(ctx context.Context, db store.RecipeStore, plans store.PlanStore, action string, args []string) error {
+	fs := flag.NewFlagSet("plan "+action, flag.ExitOnError)
+	from := fs.String("from", "", "only use meals on or after this date (YYYY-MM-DD)")
+	to := fs.String("to", "", "only use meals on or before this date (YYYY-MM-DD)")
+	format := fs.String("format", "text", "shop: write the list as \"text\", \"markdown\" or \"json\" (cost: \"json\" or \"text\")")
+	system := fs.String("units", "", "shop: give amounts in \"metric\" or \"us\" units")
+	out := fs.String("o", "", "ics: write the calendar to this file instead of stdout")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: plan " + action + " [flags] <plan>")
+	}
+	for _, date := range []string{*from, *to} {
+		if _, err := time.Parse(models.DateFormat, date); date != "" && err != nil {
+			return errors.New(`invalid date "` + date + `", use YYYY-MM-DD`)
+		}
+	}
+
+	p, err := plans.GetPlan(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	planned, err := mealplan.Load(ctx, db, p.Between(*from, *to))
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "shop":
+		f, err := shopping.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+		sys := units.None
+		if *system != "" {
+			if sys, err = units.ParseSystem(*system); err != nil {
+				return err
+			}
+		}
+		return mealplan.ShoppingList(planned, sys).Write(os.Stdout, f)
+
+	case "cost":
+		cost := mealplan.TotalCost(planned)
+		if *format == "json" {
+			data, err := json.MarshalIndent(cost, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		if cost.Total == nil {
+			fmt.Println("Total cost: unknown")
+		} else {
+			fmt.Println("Total cost:", cost.Total)
+		}
+		for _, meal := range cost.Unpriced {
+			fmt.Println("No price for", meal)
+		}
+		return nil
+
+	default:
+		w := os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return mealplan.WriteICS(w, p, planned, time.Now())
+	}
+}
@@ -0,0 +1,97 @@
+package recipe
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"golang.org/x/net/html"
+)
+
+// Budgetbytes writes the price of each ingredient in its notes, eg. "($1.80)" or
+// "(about 1 cup, $0.25*)".
+var pricePattern = regexp.MustCompile(`[$£€]\s?\d[\d,]*(?:\.\d+)?\*?`)
+
+// splitCost pulls a price out of an ingredient's notes, returning the price and whatever is left
+// of the notes. Notes without a price are returned unchanged.
+func splitCost(notes string) (*models.Money, string) {
+	loc := pricePattern.FindStringIndex(notes)
+	if loc == nil {
+		return nil, notes
+	}
+	cost, err := models.ParseMoney(strings.TrimSuffix(notes[loc[0]:loc[1]], "*"))
+	if err != nil {
+		return nil, notes
+	}
+
+	// Clean up any separators left behind by the price, eg. "(about 1 cup, )"
+	rest := strings.TrimSpace(notes[:loc[0]] + notes[loc[1]:])
+	if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+		rest = strings.Trim(rest[1:len(rest)-1], " ,;")
+		if rest != "" {
+			rest = "(" + rest + ")"
+		}
+	} else {
+		rest = strings.Trim(rest, " ,;")
+	}
+	return &cost, rest
+}
+
+// Budgetbytes gives the cost of the recipe near the top of the card, eg. "$3.39 recipe / $0.57 serving".
+func getCost(rc *html.Node) *models.RecipeCost {
+	return parseCostText(getText(rc, ".wprm-recipe-recipe_cost, .wprm-recipe-cost"))
+}
+
+// parseCostText reads text like "$3.39 recipe / $0.57 serving". A price without a label is taken as
+// the cost of the whole recipe.
+func parseCostText(text string) *models.RecipeCost {
+	var cost *models.RecipeCost
+	for _, loc := range pricePattern.FindAllStringIndex(text, -1) {
+		price, err := models.ParseMoney(strings.TrimSuffix(text[loc[0]:loc[1]], "*"))
+		if err != nil {
+			continue
+		}
+		if cost == nil {
+			cost = &models.RecipeCost{}
+		}
+		label := strings.ToLower(strings.TrimSpace(text[loc[1]:]))
+		if strings.HasPrefix(label, "serving") || strings.HasPrefix(label, "per serving") {
+			cost.Serving = &price
+		} else if cost.Recipe == nil {
+			cost.Recipe = &price
+		}
+	}
+	return cost
+}
+
+// ldCost reads the estimatedCost property, which is either text or a MonetaryAmount.
+func ldCost(v interface{}) *models.RecipeCost {
+	switch v := v.(type) {
+	case string:
+		return parseCostText(ldText(v))
+	case map[string]interface{}:
+		currency, _ := v["currency"].(string)
+		var value float64
+		switch n := v["value"].(type) {
+		case string:
+			parsed, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return nil
+			}
+			value = parsed
+		case float64:
+			value = n
+		default:
+			return nil
+		}
+		if currency == "" {
+			return nil
+		}
+		return &models.RecipeCost{
+			Recipe: &models.Money{Cents: int64(math.Round(value * 100)), Currency: currency},
+		}
+	}
+	return nil
+}
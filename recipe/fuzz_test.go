@@ -0,0 +1,122 @@
+package recipe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/ejacobg/recipe-parser/parser"
+)
+
+// seedCards are small recipe cards covering the odd markup that the extractors have to cope with.
+var seedCards = []string{
+	`<div class="wprm-recipe-container" data-recipe-id="1"><h2 class="wprm-recipe-name">Soup</h2>
+<ul class="wprm-recipe-ingredients"><li class="wprm-recipe-ingredient"><span class="wprm-recipe-ingredient-amount">1 1/2</span>
+<span class="wprm-recipe-ingredient-unit">cups</span> <span class="wprm-recipe-ingredient-name"><a href="/lentils">lentils</a></span>
+<span class="wprm-recipe-ingredient-notes">($1.20)</span></li></ul>
+<ul class="wprm-recipe-instructions"><li class="wprm-recipe-instruction">Simmer.</li></ul></div>`,
+	`<div class="wprm-recipe-container"><div class="wprm-recipe-ingredient-group"><h4 class="wprm-recipe-group-name"></h4>
+<ul class="wprm-recipe-ingredients"><li><span class="wprm-recipe-ingredient-amount"></span></li><li></li></ul></div>
+<ul class="wprm-recipe-instructions"><li class="wprm-recipe-instruction"> </li><li class="wprm-recipe-instruction"><b></b></li></ul></div>`,
+	`<div class="wprm-recipe-container"><ul class="wprm-recipe-ingredients"></ul><ul class="wprm-recipe-instructions"></ul>
+<div class="wprm-recipe-image"><img></div><span class="wprm-recipe-servings">x</span><span class="wprm-recipe-time">1 hr</span></div>`,
+	`<script type="application/ld+json">{"@type":"Recipe","name":"Soup","recipeIngredient":["1 cup lentils"],
+"recipeInstructions":[{"@type":"HowToSection","itemListElement":[{"@type":"HowToStep","text":"Simmer."}]}]}</script>
+<div class="wprm-recipe-container"><ul class="wprm-recipe-ingredients"></ul><ul class="wprm-recipe-instructions"></ul></div>`,
+}
+
+// FuzzFromHTML feeds mutated pages through FromHTML. Whatever the markup, the extractors shouldn't
+// panic, and a recipe taken from the card should account for every item in the card's lists.
+func FuzzFromHTML(f *testing.F) {
+	pages, _ := filepath.Glob("./test-data/responses/*.html")
+	for _, page := range pages {
+		data, err := os.ReadFile(page)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(data))
+	}
+	for _, card := range seedCards {
+		f.Add(card)
+	}
+
+	f.Fuzz(func(t *testing.T, page string) {
+		doc, err := html.Parse(strings.NewReader(page))
+		if err != nil {
+			return
+		}
+		res, err := FromHTML(doc)
+		if err != nil {
+			return
+		}
+		checkResult(t, doc, res)
+
+		_, err = FromHTML(doc, Strict())
+		if _, ok := err.(*ExtractionError); ok != (len(res.Warnings) > 0) {
+			t.Errorf("strict mode returned %v for %d warnings", err, len(res.Warnings))
+		}
+	})
+}
+
+// checkResult checks the properties that every parsed recipe should have.
+func checkResult(t *testing.T, doc *html.Node, res *Result) {
+	t.Helper()
+	if res.Recipe == nil {
+		t.Fatal("got a result without a recipe")
+	}
+	for i, instruction := range res.Recipe.Instructions {
+		if strings.TrimSpace(instruction) == "" {
+			t.Errorf("instruction %d is blank", i)
+		}
+	}
+	for _, w := range res.Warnings {
+		if w.Field == "" || w.Kind == "" {
+			t.Errorf("incomplete warning %+v", w)
+		}
+	}
+	if res.Strategy != WPRM {
+		return
+	}
+
+	backfilled := make(map[string]bool)
+	for _, field := range res.Backfilled {
+		backfilled[field] = true
+	}
+	card := parser.FindRecipeCard(doc)
+
+	if !backfilled["ingredients"] {
+		lists := parser.FindIngredientLists(card)
+		if len(res.Recipe.Ingredients) != len(lists) {
+			t.Errorf("got %d ingredient groups for %d lists", len(res.Recipe.Ingredients), len(lists))
+		}
+		items := 0
+		for _, list := range lists {
+			for li := list.FirstChild; li != nil; li = li.NextSibling {
+				if li.Type == html.ElementNode && li.DataAtom == atom.Li {
+					items++
+				}
+			}
+		}
+		if n := len(res.Recipe.AllIngredients()); n != items {
+			t.Errorf("got %d ingredients for %d <li> elements", n, items)
+		}
+	}
+
+	if !backfilled["instructions"] {
+		// Steps without any text are skipped, but every other step has to be kept.
+		steps := 0
+		list := parser.FindInstructionsList(card)
+		for _, li := range parser.QuerySelectorAll(list, "li.wprm-recipe-instruction") {
+			if parser.GetText(li) != "" {
+				steps++
+			}
+		}
+		if len(res.Recipe.Instructions) != steps {
+			t.Errorf("got %d instructions for %d steps with text", len(res.Recipe.Instructions), steps)
+		}
+	}
+}
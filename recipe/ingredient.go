@@ -0,0 +1,70 @@
+package recipe
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ejacobg/recipe-parser/models"
+)
+
+// Units recognized when splitting a plain ingredient line. Matching ignores case and a trailing ".".
+var knownUnits = map[string]bool{
+	"tsp": true, "tsps": true, "teaspoon": true, "teaspoons": true,
+	"tbsp": true, "tbsps": true, "tablespoon": true, "tablespoons": true,
+	"cup": true, "cups": true, "pint": true, "pints": true, "quart": true, "quarts": true,
+	"gallon": true, "gallons": true, "ml": true, "l": true, "liter": true, "liters": true,
+	"oz": true, "ounce": true, "ounces": true, "lb": true, "lbs": true, "pound": true, "pounds": true,
+	"g": true, "gram": true, "grams": true, "kg": true,
+	"clove": true, "cloves": true, "can": true, "cans": true, "bunch": true, "bunches": true,
+	"pinch": true, "dash": true, "slice": true, "slices": true, "stalk": true, "stalks": true,
+	"sprig": true, "sprigs": true, "large": true, "medium": true, "small": true,
+}
+
+// parseIngredientLine splits a single line of text like "3 lbs. russet potatoes ($1.80)" into its
+// amount, unit, name and notes. Sources such as JSON-LD only give ingredients in this form.
+func parseIngredientLine(line string) models.Ingredient {
+	ingredient := models.Ingredient{}
+	line = strings.TrimSpace(line)
+
+	// A trailing parenthetical is treated as the notes, eg. "($1.80)".
+	if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndex(line, "("); i > 0 {
+			ingredient.Notes = line[i:]
+			line = strings.TrimSpace(line[:i])
+		}
+	}
+
+	fields := strings.Fields(line)
+	var amount []string
+	for len(fields) > 0 && isAmount(fields[0]) {
+		amount = append(amount, fields[0])
+		fields = fields[1:]
+	}
+	ingredient.Amount = strings.Join(amount, " ")
+
+	if len(amount) > 0 && len(fields) > 1 && knownUnits[strings.ToLower(strings.TrimSuffix(fields[0], "."))] {
+		ingredient.Unit = fields[0]
+		fields = fields[1:]
+	}
+	ingredient.Name = strings.Join(fields, " ")
+	ingredient.Cost, ingredient.Notes = splitCost(ingredient.Notes)
+	// isAmount is loose, so anything that isn't a real amount is left without a Quantity
+	ingredient.ParseAmount()
+	return ingredient
+}
+
+// isAmount reports whether the word is made up only of digits, fractions and range separators,
+// eg. "1", "1/2", "1.5", "2-3", "½".
+func isAmount(word string) bool {
+	hasDigit := false
+	for _, r := range word {
+		switch {
+		case unicode.IsDigit(r) || unicode.Is(unicode.No, r):
+			hasDigit = true
+		case r == '/' || r == '.' || r == '-' || r == '–' || r == '⁄':
+		default:
+			return false
+		}
+	}
+	return hasDigit
+}
@@ -0,0 +1,264 @@
+package recipe
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/parser"
+
+	"golang.org/x/net/html"
+)
+
+// See https://schema.org/Recipe and https://developers.google.com/search/docs/appearance/structured-data/recipe
+
+// FromJSONLD builds a recipe from the first schema.org Recipe object found in the page's
+// <script type="application/ld+json"> blocks.
+func FromJSONLD(doc *html.Node) (*models.Recipe, error) {
+	for _, script := range parser.FindJSONLDScripts(doc) {
+		textNode := parser.GetTextNode(script)
+		if textNode == nil {
+			continue
+		}
+		var data interface{}
+		if err := json.Unmarshal([]byte(textNode.Data), &data); err != nil {
+			// Some sites ship broken JSON-LD, so skip it and keep looking.
+			continue
+		}
+		obj := findLDRecipe(data)
+		if obj == nil {
+			continue
+		}
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		ld := ldRecipe{}
+		if err = json.Unmarshal(raw, &ld); err != nil {
+			return nil, err
+		}
+		rcp := ld.toRecipe()
+		if rcp.URL == "" {
+			rcp.URL = getURL(doc)
+		}
+		// The @id is usually the URL with a "#recipe" fragment, which makes a poor key (it gets cut
+		// off in a query string), so the ID is made from the URL instead.
+		source := rcp.URL
+		if source == "" {
+			source = ld.ID
+		}
+		rcp.ID = urlID(source)
+		return rcp, nil
+	}
+	return nil, errors.New("couldn't find JSON-LD recipe")
+}
+
+// urlID makes an ID for a recipe without a recipe card (whose ID would be used otherwise) from its
+// URL: the host and path as a slug, eg. "example.com-one-pot-lentil-soup" for
+// https://www.example.com/one-pot-lentil-soup/#recipe. The ID is safe to use in a file name or a
+// query string as is.
+func urlID(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	words := strings.FieldsFunc(strings.ToLower(host+u.Path), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	})
+	return strings.Join(words, "-")
+}
+
+// findLDRecipe searches a decoded JSON-LD value for an object whose @type includes "Recipe".
+// Objects may be given on their own, as an array, or under an "@graph" key.
+func findLDRecipe(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			if obj := findLDRecipe(elem); obj != nil {
+				return obj
+			}
+		}
+	case map[string]interface{}:
+		for _, t := range ldStringList(v["@type"]) {
+			if t == "Recipe" || strings.HasSuffix(t, "/Recipe") {
+				return v
+			}
+		}
+		if graph, ok := v["@graph"]; ok {
+			return findLDRecipe(graph)
+		}
+	}
+	return nil
+}
+
+type ldRecipe struct {
+	ID           string        `json:"@id"`
+	Name         string        `json:"name"`
+	URL          string        `json:"url"`
+	Image        interface{}   `json:"image"`
+	Ingredients  interface{}   `json:"recipeIngredient"`
+	Instructions interface{}   `json:"recipeInstructions"`
+	Legacy       []interface{} `json:"ingredients"` // deprecated by schema.org, but still used
+	Description  string        `json:"description"`
+	Author       interface{}   `json:"author"`
+	PrepTime     string        `json:"prepTime"`
+	CookTime     string        `json:"cookTime"`
+	TotalTime    string        `json:"totalTime"`
+	Yield        interface{}   `json:"recipeYield"`
+	Category     interface{}   `json:"recipeCategory"`
+	Cuisine      interface{}   `json:"recipeCuisine"`
+	Keywords     interface{}   `json:"keywords"`
+	Nutrition    interface{}   `json:"nutrition"`
+	Cost         interface{}   `json:"estimatedCost"`
+}
+
+func (ld *ldRecipe) toRecipe() *models.Recipe {
+	lines := ldStringList(ld.Ingredients)
+	if len(lines) == 0 {
+		lines = ldStringList(ld.Legacy)
+	}
+	var ingredients []models.Ingredient
+	for _, line := range lines {
+		if line = ldText(line); line != "" {
+			ingredients = append(ingredients, parseIngredientLine(line))
+		}
+	}
+
+	rcp := &models.Recipe{
+		Name:         ldText(ld.Name),
+		URL:          ld.URL,
+		Image:        ldImage(ld.Image),
+		Instructions: ldInstructions(ld.Instructions),
+	}
+	if ingredients != nil {
+		rcp.Ingredients = models.IngredientGroups{{Ingredients: ingredients}}
+	}
+
+	rcp.Summary = ldText(ld.Description)
+	rcp.Author = ldName(ld.Author)
+	// Invalid durations are left empty
+	rcp.PrepTime, _ = models.ParseDuration(ld.PrepTime)
+	rcp.CookTime, _ = models.ParseDuration(ld.CookTime)
+	rcp.TotalTime, _ = models.ParseDuration(ld.TotalTime)
+	for _, yield := range ldStringList(ld.Yield) {
+		servings, unit := parseYield(yield)
+		if servings == 0 {
+			continue
+		}
+		// Prefer a yield that comes with a unit, eg. ["6", "6 servings"]
+		if rcp.Servings == 0 || rcp.ServingsUnit == "" {
+			rcp.Servings, rcp.ServingsUnit = servings, unit
+		}
+	}
+	if n, ok := ld.Yield.(float64); ok {
+		rcp.Servings = n
+	}
+	rcp.Course = ldList(ld.Category)
+	rcp.Cuisine = ldList(ld.Cuisine)
+	rcp.Keywords = ldList(ld.Keywords)
+	rcp.Nutrition = ldNutrition(ld.Nutrition)
+	rcp.Cost = ldCost(ld.Cost)
+	return rcp
+}
+
+// ldList accepts an array of strings or a single comma separated string.
+func ldList(v interface{}) (list []string) {
+	for _, s := range ldStringList(v) {
+		list = append(list, splitList(ldText(s))...)
+	}
+	return
+}
+
+// ldName returns the name of a Person or Organization, or the first of an array of them.
+func ldName(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return ldText(v)
+	case map[string]interface{}:
+		name, _ := v["name"].(string)
+		return ldText(name)
+	case []interface{}:
+		for _, elem := range v {
+			if name := ldName(elem); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// ldText unescapes any HTML entities and trims surrounding whitespace.
+func ldText(s string) string {
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// ldStringList accepts either a single string or an array of strings.
+func ldStringList(v interface{}) (list []string) {
+	switch v := v.(type) {
+	case string:
+		list = append(list, v)
+	case []interface{}:
+		for _, elem := range v {
+			if s, ok := elem.(string); ok {
+				list = append(list, s)
+			}
+		}
+	}
+	return
+}
+
+// ldImage returns the first image URL. Images can be a URL, an ImageObject, or an array of either.
+func ldImage(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if url, ok := v["url"].(string); ok {
+			return url
+		}
+		if url, ok := v["contentUrl"].(string); ok {
+			return url
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if url := ldImage(elem); url != "" {
+				return url
+			}
+		}
+	}
+	return ""
+}
+
+// ldInstructions flattens the instructions into a list of steps. Instructions can be a single
+// block of text, a list of strings, a list of HowToSteps, or a list of HowToSections that each
+// contain HowToSteps.
+func ldInstructions(v interface{}) (steps []string) {
+	switch v := v.(type) {
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if line = ldText(line); line != "" {
+				steps = append(steps, line)
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			steps = append(steps, ldInstructions(elem)...)
+		}
+	case map[string]interface{}:
+		if items, ok := v["itemListElement"]; ok {
+			return ldInstructions(items)
+		}
+		text, _ := v["text"].(string)
+		if text == "" {
+			text, _ = v["name"].(string)
+		}
+		if text = ldText(text); text != "" {
+			steps = append(steps, text)
+		}
+	}
+	return
+}
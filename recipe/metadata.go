@@ -0,0 +1,114 @@
+package recipe
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/parser"
+	"golang.org/x/net/html"
+)
+
+// getMetadata fills in the optional details from the recipe card: times, servings, course,
+// cuisine, keywords, author, summary, nutrition and cost. getSummary reads the summary, so that it
+// can be kept as Markdown. Details that are missing from the card are left empty without a
+// warning, since not every recipe has them.
+func (w *warnings) getMetadata(rc *html.Node, rcp *models.Recipe, getSummary func(*html.Node) string) {
+	rcp.Summary = getSummary(parser.QuerySelector(rc, ".wprm-recipe-summary"))
+	rcp.Author = getText(rc, "span.wprm-recipe-author")
+	rcp.PrepTime = w.getTime(rc, "prep_time", "prepTime")
+	rcp.CookTime = w.getTime(rc, "cook_time", "cookTime")
+	rcp.TotalTime = w.getTime(rc, "total_time", "totalTime")
+	rcp.Servings, rcp.ServingsUnit = w.getServings(rc)
+	rcp.Course = splitList(getText(rc, "span.wprm-recipe-course"))
+	rcp.Cuisine = splitList(getText(rc, "span.wprm-recipe-cuisine"))
+	rcp.Keywords = splitList(getText(rc, "span.wprm-recipe-keyword"))
+	rcp.Nutrition = w.getNutrition(rc)
+	rcp.Cost = getCost(rc)
+}
+
+// getText returns the text of the first element matching the selector.
+func getText(node *html.Node, selector string) string {
+	return parser.GetText(parser.QuerySelector(node, selector))
+}
+
+// WPRM splits each time into separate days, hours and minutes elements, eg.
+// <span class="wprm-recipe-details wprm-recipe-prep_time wprm-recipe-prep_time-minutes">15</span>
+func (w *warnings) getTime(rc *html.Node, key, field string) models.Duration {
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"days", 24 * time.Hour},
+		{"hours", time.Hour},
+		{"minutes", time.Minute},
+	}
+
+	var total time.Duration
+	for _, u := range units {
+		text := getText(rc, "span.wprm-recipe-details.wprm-recipe-"+key+"-"+u.suffix)
+		if text == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			w.add(UnexpectedStructure, field, "time is not a number: "+text)
+			return 0
+		}
+		total += time.Duration(n * float64(u.unit))
+	}
+	return models.Duration(total)
+}
+
+func (w *warnings) getServings(rc *html.Node) (float64, string) {
+	text := getText(rc, "span.wprm-recipe-servings")
+	if text == "" {
+		return 0, ""
+	}
+	servings, unit := parseYield(text)
+	if servings == 0 {
+		w.add(UnexpectedStructure, "servings", "servings is not a number: "+text)
+		return 0, ""
+	}
+	if u := getText(rc, ".wprm-recipe-servings-unit"); u != "" {
+		unit = u
+	}
+	return servings, unit
+}
+
+// parseYield splits text like "6 servings" or "12" into a number and a unit label.
+// Ranges like "4-6" use the first number. It returns 0 if the text doesn't start with a number.
+func parseYield(text string) (float64, string) {
+	n, rest, ok := parseMeasure(text)
+	if !ok {
+		return 0, ""
+	}
+	rest = strings.TrimLeft(rest, "-–0123456789. ")
+	return n, strings.TrimSpace(rest)
+}
+
+// parseMeasure splits text like "380 mg" or "1,200 kcal" into its leading number and the rest of
+// the text. The boolean is false if the text doesn't start with a number.
+func parseMeasure(text string) (float64, string, bool) {
+	text = strings.TrimSpace(text)
+	end := 0
+	for end < len(text) && (text[end] >= '0' && text[end] <= '9' || text[end] == '.' || text[end] == ',') {
+		end++
+	}
+	n, err := strconv.ParseFloat(strings.ReplaceAll(text[:end], ",", ""), 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return n, strings.TrimSpace(text[end:]), true
+}
+
+// splitList splits a comma separated list, eg. "Side Dish, Main Course".
+func splitList(text string) (list []string) {
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return
+}
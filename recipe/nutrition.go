@@ -0,0 +1,81 @@
+package recipe
+
+import (
+	"github.com/ejacobg/recipe-parser/models"
+	"golang.org/x/net/html"
+)
+
+// Keys used for each nutrition value by WPRM's class names and by schema.org, in the same order as
+// models.NutritionFields.
+var nutritionKeys = []struct {
+	wprm, ld string
+}{
+	{"serving_size", "servingSize"},
+	{"calories", "calories"},
+	{"carbohydrates", "carbohydrateContent"},
+	{"protein", "proteinContent"},
+	{"fat", "fatContent"},
+	{"saturated_fat", "saturatedFatContent"},
+	{"cholesterol", "cholesterolContent"},
+	{"sodium", "sodiumContent"},
+	{"potassium", "potassiumContent"},
+	{"fiber", "fiberContent"},
+	{"sugar", "sugarContent"},
+}
+
+// WPRM writes each value as a label, value and unit, eg.
+// <span class="wprm-nutrition-label-text-nutrition-container wprm-nutrition-label-text-nutrition-container-calories">
+// <span class="wprm-nutrition-label-text-nutrition-label">Calories: </span>
+// <span class="wprm-nutrition-label-text-nutrition-value">269</span>
+// <span class="wprm-nutrition-label-text-nutrition-unit">kcal</span></span>
+func (w *warnings) getNutrition(rc *html.Node) *models.Nutrition {
+	var nutrition *models.Nutrition
+	for i, key := range nutritionKeys {
+		container := ".wprm-nutrition-label-text-nutrition-container-" + key.wprm
+		value := getText(rc, container+" .wprm-nutrition-label-text-nutrition-value")
+		if value == "" {
+			continue
+		}
+		n, _, ok := parseMeasure(value)
+		if !ok {
+			w.add(UnexpectedStructure, "nutrition."+models.NutritionFields[i].Name, "value is not a number: "+value)
+			continue
+		}
+		if nutrition == nil {
+			nutrition = &models.Nutrition{}
+		}
+		unit := getText(rc, container+" .wprm-nutrition-label-text-nutrition-unit")
+		*models.NutritionFields[i].Field(nutrition) = &models.Measure{Value: n, Unit: unit}
+	}
+	return nutrition
+}
+
+// ldNutrition reads a schema.org NutritionInformation object, where each value is text like
+// "269 kcal" or "380 mg".
+func ldNutrition(v interface{}) *models.Nutrition {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var nutrition *models.Nutrition
+	for i, key := range nutritionKeys {
+		var value float64
+		var unit string
+		switch v := obj[key.ld].(type) {
+		case string:
+			var ok bool
+			if value, unit, ok = parseMeasure(ldText(v)); !ok {
+				continue
+			}
+		case float64:
+			value = v
+		default:
+			continue
+		}
+		if nutrition == nil {
+			nutrition = &models.Nutrition{}
+		}
+		*models.NutritionFields[i].Field(nutrition) = &models.Measure{Value: value, Unit: unit}
+	}
+	return nutrition
+}
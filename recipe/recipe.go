@@ -3,8 +3,8 @@ package recipe
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
-	"strings"
 
 	"github.com/ejacobg/recipe-parser/models"
 	"github.com/ejacobg/recipe-parser/parser"
@@ -13,31 +13,176 @@ import (
 	"golang.org/x/net/html"
 )
 
+// Strategy names a method for extracting a recipe from a page.
+type Strategy string
+
+const (
+	// JSONLD reads the schema.org Recipe object embedded in the page.
+	JSONLD Strategy = "json-ld"
+	// WPRM scrapes the WP Recipe Maker recipe card.
+	WPRM Strategy = "wprm"
+)
+
+var errDisabled = errors.New("extraction strategy not enabled")
+
+// Result is a recipe along with a report of how it was extracted.
+type Result struct {
+	Recipe *models.Recipe
+	// Strategy is the extractor that produced the recipe.
+	Strategy Strategy
+	// Backfilled lists the fields that the WPRM extractor left empty and were filled in from the
+	// JSON-LD recipe instead.
+	Backfilled []string
+	// Warnings lists the fields that couldn't be extracted. These fields are left empty.
+	Warnings []Warning
+	// Snapshot is the page the recipe was parsed from, if it was downloaded (see sites.Fetch).
+	Snapshot *models.Snapshot
+}
+
 // FromHTML takes a document and attempts to build a recipe from it.
-func FromHTML(doc *html.Node) (*models.Recipe, error) {
+// Both the JSON-LD and WPRM extractors are tried. The recipe card has more detail (eg. ingredient
+// groups and notes), so its result is used whenever the card exists, with any missing fields
+// filled in from the JSON-LD. If there is no recipe card, the JSON-LD recipe is used on its own.
+//
+// Fields that can't be extracted are reported in Result.Warnings. With the Strict option, any
+// warnings are returned as an *ExtractionError instead.
+func FromHTML(doc *html.Node, opts ...Option) (*Result, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var (
+		ld, rcp    *models.Recipe
+		ldErr, err = errDisabled, errDisabled
+		fromCard   warnings
+		res        *Result
+	)
+	if o.uses(JSONLD) {
+		ld, ldErr = FromJSONLD(doc)
+	}
+	if o.uses(WPRM) {
+		rcp, fromCard, err = FromWPRM(doc, opts...)
+	}
+
+	switch {
+	case err == nil:
+		res = &Result{Recipe: rcp, Strategy: WPRM}
+		if ldErr == nil {
+			res.Backfilled = backfill(rcp, ld)
+			fromCard.drop(res.Backfilled...)
+		}
+	case ldErr == nil:
+		res = &Result{Recipe: ld, Strategy: JSONLD}
+	case err == errDisabled:
+		return nil, ldErr
+	case ldErr == errDisabled:
+		return nil, err
+	default:
+		return nil, fmt.Errorf("%v; %v", ldErr, err)
+	}
+
+	res.Recipe.TotalCost()
+	fromCard.checkRequired(res.Recipe)
+	res.Warnings = fromCard
+	if o.strict && len(res.Warnings) > 0 {
+		return nil, &ExtractionError{res.Warnings}
+	}
+	return res, nil
+}
+
+// backfill copies any fields that are empty in dst from src, returning the names of the fields
+// that were copied.
+func backfill(dst, src *models.Recipe) (fields []string) {
+	fill := func(name string, dst *string, src string) {
+		if *dst == "" && src != "" {
+			*dst = src
+			fields = append(fields, name)
+		}
+	}
+	fill("id", &dst.ID, src.ID)
+	fill("name", &dst.Name, src.Name)
+	fill("url", &dst.URL, src.URL)
+	fill("image", &dst.Image, src.Image)
+	if len(dst.AllIngredients()) == 0 && len(src.AllIngredients()) > 0 {
+		dst.Ingredients = src.Ingredients
+		fields = append(fields, "ingredients")
+	}
+	if len(dst.Instructions) == 0 && len(src.Instructions) > 0 {
+		dst.Instructions = src.Instructions
+		fields = append(fields, "instructions")
+	}
+
+	fill("summary", &dst.Summary, src.Summary)
+	fill("author", &dst.Author, src.Author)
+	fillDuration := func(name string, dst *models.Duration, src models.Duration) {
+		if *dst == 0 && src != 0 {
+			*dst = src
+			fields = append(fields, name)
+		}
+	}
+	fillDuration("prepTime", &dst.PrepTime, src.PrepTime)
+	fillDuration("cookTime", &dst.CookTime, src.CookTime)
+	fillDuration("totalTime", &dst.TotalTime, src.TotalTime)
+	if dst.Servings == 0 && src.Servings != 0 {
+		dst.Servings, dst.ServingsUnit = src.Servings, src.ServingsUnit
+		fields = append(fields, "servings")
+	}
+	fillList := func(name string, dst *[]string, src []string) {
+		if len(*dst) == 0 && len(src) > 0 {
+			*dst = src
+			fields = append(fields, name)
+		}
+	}
+	fillList("course", &dst.Course, src.Course)
+	fillList("cuisine", &dst.Cuisine, src.Cuisine)
+	fillList("keywords", &dst.Keywords, src.Keywords)
+	if dst.Cost == nil && src.Cost != nil {
+		dst.Cost = src.Cost
+		fields = append(fields, "cost")
+	}
+	if dst.Nutrition == nil && src.Nutrition != nil {
+		dst.Nutrition = src.Nutrition
+		fields = append(fields, "nutrition")
+	}
+	return
+}
+
+// FromWPRM builds a recipe by scraping the WP Recipe Maker recipe card.
+// An error is only returned if the card (or its lists) can't be found. Anything else that goes
+// wrong is returned as a warning, and the affected field is left empty. Of the options, only
+// Markdown applies.
+func FromWPRM(doc *html.Node, opts ...Option) (*models.Recipe, []Warning, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	recipeCard := parser.FindRecipeCard(doc)
 	if recipeCard == nil {
-		return nil, errors.New("couldn't find recipe card")
+		return nil, nil, errors.New("couldn't find recipe card")
 	}
 
 	ingredientLists := parser.FindIngredientLists(recipeCard)
 	if ingredientLists == nil {
-		return nil, errors.New("couldn't find ingredients list(s)")
+		return nil, nil, errors.New("couldn't find ingredients list(s)")
 	}
 
 	instructionsList := parser.FindInstructionsList(recipeCard)
 	if instructionsList == nil {
-		return nil, errors.New("instructions list does not exist")
+		return nil, nil, errors.New("instructions list does not exist")
 	}
 
-	return &models.Recipe{
+	w := warnings{}
+	rcp := &models.Recipe{
 		ID:           getID(recipeCard),
-		Name:         getName(recipeCard),
+		Name:         w.getName(recipeCard),
 		URL:          getURL(doc),
-		Image:        getImage(recipeCard),
-		Ingredients:  ingredientsFromLists(ingredientLists),
-		Instructions: getInstructions(instructionsList),
-	}, nil
+		Image:        w.getImage(recipeCard),
+		Ingredients:  w.groupsFromLists(ingredientLists),
+		Instructions: w.getInstructions(instructionsList, o.text()),
+	}
+	w.getMetadata(recipeCard, rcp, o.text())
+	return rcp, w, nil
 }
 
 // FromJSON reads from a JSON file and returns a recipe.
@@ -55,126 +200,129 @@ func FromJSON(path string) (*models.Recipe, error) {
 }
 
 func getID(rc *html.Node) string {
-	for _, a := range rc.Attr {
-		if a.Key == "data-recipe-id" {
-			return a.Val
-		}
-	}
-	return ""
+	return parser.GetAttribute(rc, "data-recipe-id")
 }
 
-// Probably replace nil checks with panics because they shouldn't realistically happen
-func getName(rc *html.Node) string {
-	headerNode := parser.GetElementWithClass(rc, atom.H2, "wprm-recipe-name wprm-block-text-bold")
+func (w *warnings) getName(rc *html.Node) string {
+	headerNode := parser.QuerySelector(rc, "h2.wprm-recipe-name")
 	if headerNode == nil {
-		return "Error: headerNode not found"
+		return ""
 	}
-	textNode := headerNode.FirstChild
-	if textNode == nil {
-		return "Error: textNode not found"
+	name := parser.GetText(headerNode)
+	if name == "" {
+		w.add(EmptyText, "name", "recipe name header has no text")
 	}
-	return textNode.Data
+	return name
 }
 
 func getURL(doc *html.Node) string {
-	matcher := func(node *html.Node) bool {
-		if node.Type == html.ElementNode && node.DataAtom == atom.Link {
-			for _, a := range node.Attr {
-				if a.Key == "rel" && a.Val == "canonical" {
-					return true
-				}
-			}
-		}
-		return false
-	}
-	node := parser.FindNode(doc, matcher)
+	node := parser.QuerySelector(doc, "link[rel=canonical]")
 	if node == nil {
 		return ""
 	}
-	for _, a := range node.Attr {
-		if a.Key == "href" {
-			return a.Val
-		}
-	}
-	return ""
+	return parser.GetAttribute(node, "href")
 }
 
-func getImage(rc *html.Node) string {
+func (w *warnings) getImage(rc *html.Node) string {
 	// The class list in the Elements tab has a different order than what is actually written in the raw HTML
 	// Code from the HTTP response (line 999) looks like this: lazy lazy-hidden attachment-200x200 size-200x200
 	// The rendered HTML uses this: lazy-hidden attachment-200x200 size-200x200
-	imgNode := parser.GetElementWithClass(
-		rc, atom.Img, "attachment-268x268 size-268x268 perfmatters-lazy",
-	)
+	// Matching on individual classes means the order no longer matters.
+	imgNode := parser.QuerySelector(rc, ".wprm-recipe-image img, img.attachment-268x268")
 	if imgNode == nil {
-		return "Error: imgNode not found"
+		return ""
 	}
-	for _, a := range imgNode.Attr {
-		if a.Key == "data-pin-media" {
-			return a.Val
-		}
+	link := parser.GetAttribute(imgNode, "data-pin-media")
+	if link == "" {
+		w.add(UnexpectedStructure, "image", "recipe image has no data-pin-media attribute")
 	}
-	return "Error: could not find image link"
+	return link
 }
 
-// Some recipes may have multiple ingredients lists
-func ingredientsFromLists(lists []*html.Node) (ingredients []models.Ingredient) {
-	for _, list := range lists {
-		ingredients = append(ingredients, getIngredients(list)...)
+// Some recipes may have multiple ingredients lists, each under their own header.
+// Lists without a header are given an empty group name.
+func (w *warnings) groupsFromLists(lists []*html.Node) (groups models.IngredientGroups) {
+	for i, list := range lists {
+		groups = append(
+			groups, models.IngredientGroup{
+				Name:        getGroupName(list),
+				Ingredients: w.getIngredients(list, fmt.Sprintf("ingredients[%d]", i)),
+			},
+		)
 	}
 	return
 }
 
-// Assuming that the instructions list is parsed in order
-func getInstructions(list *html.Node) []string {
+func getGroupName(list *html.Node) string {
+	headerNode := parser.FindIngredientGroupName(list)
+	if headerNode == nil {
+		return ""
+	}
+	return parser.GetText(headerNode)
+}
+
+// Assuming that the instructions list is parsed in order. Steps often link to other recipes or
+// bold a word, so the text comes from every element in the step (see parser.GetText).
+func (w *warnings) getInstructions(list *html.Node, readText func(*html.Node) string) []string {
 	var instructions []string
-	for li := list.FirstChild; li != nil; li = li.NextSibling {
-		if li.Type == html.ElementNode && li.DataAtom == atom.Li {
-			for _, a := range li.Attr {
-				if a.Key == "class" && a.Val == "wprm-recipe-instruction" {
-					textNode := parser.GetTextNode(li)
-					if textNode == nil {
-						// panic
-					}
-					instructions = append(instructions, textNode.Data)
-				}
-			}
+	for i, li := range parser.QuerySelectorAll(list, "li.wprm-recipe-instruction") {
+		text := readText(li)
+		if text == "" {
+			w.add(EmptyText, fmt.Sprintf("instructions[%d]", i), "instruction has no text")
+			continue
 		}
+		instructions = append(instructions, text)
 	}
 	return instructions
 }
 
 // Assumes ingredient list is passed
-func getIngredients(list *html.Node) []models.Ingredient {
-	classes := []string{
-		"wprm-recipe-ingredient-amount",
-		"wprm-recipe-ingredient-unit",
-		"wprm-recipe-ingredient-name",
-		"wprm-recipe-ingredient-notes wprm-recipe-ingredient-notes-normal",
+func (w *warnings) getIngredients(list *html.Node, field string) []models.Ingredient {
+	selectors := []string{
+		".wprm-recipe-ingredient-amount",
+		".wprm-recipe-ingredient-unit",
+		".wprm-recipe-ingredient-name",
+		".wprm-recipe-ingredient-notes",
 	}
+	names := []string{"amount", "unit", "name", "notes"}
 	var ingredients []models.Ingredient
 	for li := list.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			// skip whitespace between list items
+			continue
+		}
 		ingredient := models.Ingredient{}
-		for index, class := range classes {
-			spanNode := parser.GetElementWithClass(li, atom.Span, class)
+		path := fmt.Sprintf("%s[%d]", field, len(ingredients))
+		for index, selector := range selectors {
+			spanNode := parser.QuerySelector(li, selector)
 			if spanNode == nil {
 				// not all ingredients define all 4 classes
 				continue
 			}
-			// Sometimes ingredients may be contained within links
-			textNode := parser.GetTextNode(spanNode)
-			textNode.Data = strings.TrimSpace(textNode.Data)
+			// Sometimes ingredients may be contained within links, or only partly (eg. "<a>garlic</a>, minced")
+			text := parser.GetText(spanNode)
+			if text == "" {
+				w.add(EmptyText, path+"."+names[index], "")
+				continue
+			}
 			switch index {
 			case 0:
-				ingredient.Amount = textNode.Data
+				ingredient.Amount = text
 			case 1:
-				ingredient.Unit = textNode.Data
+				ingredient.Unit = text
 			case 2:
-				ingredient.Name = textNode.Data
+				ingredient.Name = text
 			case 3:
-				ingredient.Notes = textNode.Data
+				ingredient.Notes = text
 			}
 		}
+		if ingredient.Name == "" {
+			w.add(MissingField, path+".name", "")
+		}
+		ingredient.Cost, ingredient.Notes = splitCost(ingredient.Notes)
+		if err := ingredient.ParseAmount(); err != nil {
+			w.add(UnexpectedStructure, path+".amount", err.Error())
+		}
 		ingredients = append(ingredients, ingredient)
 	}
 	return ingredients
@@ -1,11 +1,17 @@
 package recipe
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ejacobg/recipe-parser/models"
-	"github.com/ejacobg/recipe-parser/parser"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/net/html"
@@ -19,17 +25,8 @@ func assert(t testing.TB, got, want *models.Recipe) {
 	}
 }
 
-// setup function
-func initTest(t testing.TB, name string) *html.Node {
-	t.Helper()
-	doc, err := parseFromFile(name)
-	if err != nil {
-		t.Error("Error parsing file:", err)
-		return nil
-	}
-	rc := parser.FindRecipeCard(doc)
-	return rc
-}
+// update rewrites the solutions from the parser's current output, see TestFixtures.
+var update = flag.Bool("update", false, "rewrite test-data/solutions from the parser's current output")
 
 // read data from static file instead of making network calls
 func parseFromFile(name string) (*html.Node, error) {
@@ -37,99 +34,397 @@ func parseFromFile(name string) (*html.Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 	return html.Parse(file)
 }
 
-// grab recipe card and use that for all tests
+// TestFixtures parses every page in test-data/responses and compares the recipe with the solution
+// of the same name in test-data/solutions. Pages are added with "recipe-parser fixture <recipe>".
+// After a change to the parser, "go test ./recipe -run TestFixtures -update -v" rewrites the
+// solutions and prints what changed in each, so check that the changes are the ones you wanted.
+func TestFixtures(t *testing.T) {
+	pages, err := filepath.Glob("./test-data/responses/*.html")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(pages) == 0 {
+		t.Fatal("no fixtures in test-data/responses")
+	}
+
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(name, func(t *testing.T) {
+			checkFixture(t, name)
+		})
+	}
+
+	// A solution without a page is never checked, which is easy to miss.
+	solutions, _ := filepath.Glob("./test-data/solutions/*.json")
+	for _, solution := range solutions {
+		name := strings.TrimSuffix(filepath.Base(solution), ".json")
+		if _, err := os.Stat("./test-data/responses/" + name + ".html"); err != nil {
+			t.Errorf("%s has no page in test-data/responses", solution)
+		}
+	}
+}
+
+// The pages that the tests below were written against have been lost. They are skipped until
+// they are captured again with "recipe-parser fixture <recipe>", and then checked like any other.
 
 func TestSimple(t *testing.T) {
-	tests := []string{
-		"slow-cooker-mashed-potatoes",
-		"olive-oil-mashed-potatoes",
+	runFixtures(t, "slow-cooker-mashed-potatoes", "olive-oil-mashed-potatoes")
+}
+
+// The whole ingredient is a link, eg. <a href="/lentils">lentils</a>.
+func TestIngredientContainsLink(t *testing.T) {
+	runFixtures(t, "beef-cabbage-stir-fry", "beef-taco-pasta")
+}
+
+func TestMultipleIngredientLists(t *testing.T) {
+	runFixtures(t, "beef-cabbage-stir-fry", "chili-roasted-potatoes", "fluffy-garlic-herb-mashed-potatoes")
+}
+
+// runFixtures checks the named fixtures, skipping the ones that haven't been captured.
+func runFixtures(t *testing.T, names ...string) {
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			if _, err := os.Stat("./test-data/responses/" + name + ".html"); err != nil {
+				t.Skip("fixture missing: " + name + `, capture it with "recipe-parser fixture ` + name + `"`)
+			}
+			checkFixture(t, name)
+		})
 	}
+}
 
-	for _, test := range tests {
-		rc := initTest(t, "./test-data/responses/"+test+".html")
-		if rc == nil {
-			t.Error("Error: couldn't find recipe card")
+// checkFixture compares the recipe parsed from the fixture's page with its solution.
+func checkFixture(t *testing.T, name string) {
+	t.Helper()
+	page := "./test-data/responses/" + name + ".html"
+	solution := "./test-data/solutions/" + name + ".json"
+	doc, err := parseFromFile(page)
+	if err != nil {
+		t.Fatal("Error parsing file:", err)
+	}
+	got, err := FromHTML(doc)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if *update {
+		updateSolution(t, solution, got.Recipe)
+		return
+	}
+
+	want, err := FromJSON(solution)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("%s has no solution, run the tests with -update to create it", page)
+	} else if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if diff := cmp.Diff(want, got.Recipe); diff != "" {
+		t.Errorf("recipe differs from %s (-want +got):\n%s", solution, diff)
+	}
+}
+
+// updateSolution overwrites the solution with the recipe, logging the changes.
+func updateSolution(t *testing.T, solution string, got *models.Recipe) {
+	t.Helper()
+	want, err := FromJSON(solution)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		t.Log("created", solution)
+	case err != nil:
+		t.Fatal("Error:", err)
+	default:
+		diff := cmp.Diff(want, got)
+		if diff == "" {
 			return
 		}
-		t.Run(
-			test, func(t *testing.T) {
-				got, err := FromHTML(rc)
-				if err != nil {
-					t.Error("Error:", err)
-					return
-				}
-				want, err := FromJSON("./test-data/solutions/" + test + ".json")
-				if err != nil {
-					t.Error("Error:", err)
-					return
-				}
-				assert(t, got, want)
+		t.Logf("updated %s (-old +new):\n%s", solution, diff)
+	}
+
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err = os.WriteFile(solution, append(data, '\n'), 0644); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+// With the Markdown option, the links and emphasis in the instructions and summary are kept.
+func TestMarkdown(t *testing.T) {
+	doc, err := parseFromFile("./test-data/responses/wprm-inline-markup.html")
+	if err != nil {
+		t.Fatal("Error parsing file:", err)
+	}
+
+	got, err := FromHTML(doc, Markdown())
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	instructions := []string{
+		"Cook the [spaghetti](https://www.budgetbytes.com/spaghetti/) according to the package directions, then drain.",
+		"Melt the butter over **medium-low** heat, add the garlic, and stir for *one* minute.",
+		"Toss the noodles in the sauce. Top with parsley.",
+	}
+	if diff := cmp.Diff(instructions, got.Recipe.Instructions); diff != "" {
+		t.Errorf("instructions differ (-want +got):\n%s", diff)
+	}
+	if want := "Pantry noodles tossed in [garlic butter](https://www.budgetbytes.com/garlic-butter/)."; got.Recipe.Summary != want {
+		t.Errorf("got summary %q, want %q", got.Recipe.Summary, want)
+	}
+	// The other fields are plain text either way.
+	if want := "garlic, minced"; got.Recipe.Ingredients[0].Ingredients[2].Name != want {
+		t.Errorf("got ingredient %q, want %q", got.Recipe.Ingredients[0].Ingredients[2].Name, want)
+	}
+}
+
+// Group headers come from the <h4> preceding each list inside the group <div>.
+func TestIngredientGroups(t *testing.T) {
+	card := `<div class="wprm-recipe-container" data-recipe-id="1">
+<div class="wprm-recipe-ingredient-group">
+<ul class="wprm-recipe-ingredients">
+<li class="wprm-recipe-ingredient"><span class="wprm-recipe-ingredient-amount">3</span> <span class="wprm-recipe-ingredient-unit">lbs.</span> <span class="wprm-recipe-ingredient-name">potatoes</span></li>
+</ul>
+</div>
+<div class="wprm-recipe-ingredient-group">
+<h4 class="wprm-recipe-group-name wprm-recipe-ingredient-group-name wprm-block-text-bold">Garnish</h4>
+<ul class="wprm-recipe-ingredients">
+<li class="wprm-recipe-ingredient"><span class="wprm-recipe-ingredient-amount">1</span> <span class="wprm-recipe-ingredient-unit">bunch</span> <span class="wprm-recipe-ingredient-name">chives</span></li>
+</ul>
+</div>
+<ul class="wprm-recipe-instructions"><li class="wprm-recipe-instruction">Boil.</li></ul>
+</div>`
+	doc, err := html.Parse(strings.NewReader(card))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	got, err := FromHTML(doc)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	want := models.IngredientGroups{
+		{
+			Ingredients: []models.Ingredient{
+				{Amount: "3", Unit: "lbs.", Name: "potatoes", Quantity: &models.Quantity{Value: 3}},
 			},
-		)
+		},
+		{
+			Name: "Garnish",
+			Ingredients: []models.Ingredient{
+				{Amount: "1", Unit: "bunch", Name: "chives", Quantity: &models.Quantity{Value: 1}},
+			},
+		},
+	}
+	if !cmp.Equal(got.Recipe.Ingredients, want) {
+		t.Errorf("got %v, want %v", got.Recipe.Ingredients, want)
 	}
 }
 
-// Ingredients may partially (need example) or fully be contained within a link
-func TestIngredientContainsLink(t *testing.T) {
-	tests := []string{
-		"beef-cabbage-stir-fry", // fully contained
-		"beef-taco-pasta",       // fully contained
+// Recipes saved before ingredient groups existed store a flat list of ingredients. The test file is
+// a copy of one from ../database, kept apart from the solutions since it has no page.
+func TestFromJSONFlatIngredients(t *testing.T) {
+	got, err := FromJSON("./test-data/legacy/slow-cooker-mashed-potatoes.json")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(got.Ingredients) != 1 || got.Ingredients[0].Name != "" {
+		t.Fatalf("got %d groups, want a single unnamed group", len(got.Ingredients))
+	}
+	if n := len(got.AllIngredients()); n != 7 {
+		t.Errorf("got %d ingredients, want 7", n)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	regrouped := &models.Recipe{}
+	if err = json.Unmarshal(data, regrouped); err != nil {
+		t.Fatal("Error:", err)
+	}
+	assert(t, regrouped, got)
+}
+
+func TestJSONLD(t *testing.T) {
+	page := `<html><head>
+<link rel="canonical" href="https://www.budgetbytes.com/slow-cooker-mashed-potatoes/">
+<script type="application/ld+json">{"@context":"https://schema.org","@graph":[
+{"@type":"WebPage","@id":"https://www.budgetbytes.com/slow-cooker-mashed-potatoes/"},
+{"@type":"Recipe","@id":"https://www.budgetbytes.com/slow-cooker-mashed-potatoes/#recipe",
+"name":"Slow Cooker Mashed Potatoes",
+"description":"Creamy potatoes made in the slow cooker.",
+"author":{"@type":"Person","name":"Beth - Budget Bytes"},
+"prepTime":"PT15M","cookTime":"PT3H","totalTime":"PT3H15M",
+"recipeYield":["6","6 servings"],"recipeCategory":["Side Dish"],"recipeCuisine":["American"],
+"keywords":"mashed potatoes, slow cooker",
+"estimatedCost":{"@type":"MonetaryAmount","currency":"USD","value":"2.50"},
+"nutrition":{"@type":"NutritionInformation","calories":"269 kcal","carbohydrateContent":"39 g","sodiumContent":"1,180 mg","fiberContent":"x"},
+"image":["https://www.budgetbytes.com/potatoes.jpg","https://www.budgetbytes.com/potatoes-1x1.jpg"],
+"recipeIngredient":["3 lbs. russet potatoes ($1.80)","1/4 tsp Freshly cracked black pepper ($0.05)","salt to taste"],
+"recipeInstructions":[{"@type":"HowToSection","name":"Cook","itemListElement":[
+{"@type":"HowToStep","text":"Wash and peel the potatoes."},
+{"@type":"HowToStep","text":"Cook on high for three hours."}]},
+{"@type":"HowToStep","text":"Mash &amp; serve."}]}
+]}</script>
+</head><body></body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	got, err := FromHTML(doc)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if got.Strategy != JSONLD {
+		t.Errorf("got strategy %q, want %q", got.Strategy, JSONLD)
+	}
+	want := &models.Recipe{
+		ID:    "budgetbytes.com-slow-cooker-mashed-potatoes",
+		Name:  "Slow Cooker Mashed Potatoes",
+		URL:   "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/",
+		Image: "https://www.budgetbytes.com/potatoes.jpg",
+		Ingredients: models.IngredientGroups{
+			{
+				Ingredients: []models.Ingredient{
+					{
+						Amount: "3", Unit: "lbs.", Name: "russet potatoes",
+						Cost:     &models.Money{Cents: 180, Currency: "USD"},
+						Quantity: &models.Quantity{Value: 3},
+					},
+					{
+						Amount: "1/4", Unit: "tsp", Name: "Freshly cracked black pepper",
+						Cost:     &models.Money{Cents: 5, Currency: "USD"},
+						Quantity: &models.Quantity{Value: 0.25},
+					},
+					{Name: "salt to taste", Quantity: &models.Quantity{ToTaste: true}},
+				},
+			},
+		},
+		Instructions: []string{
+			"Wash and peel the potatoes.",
+			"Cook on high for three hours.",
+			"Mash & serve.",
+		},
+		Summary:      "Creamy potatoes made in the slow cooker.",
+		Author:       "Beth - Budget Bytes",
+		PrepTime:     models.Duration(15 * time.Minute),
+		CookTime:     models.Duration(3 * time.Hour),
+		TotalTime:    models.Duration(3*time.Hour + 15*time.Minute),
+		Servings:     6,
+		ServingsUnit: "servings",
+		Course:       []string{"Side Dish"},
+		Cuisine:      []string{"American"},
+		Keywords:     []string{"mashed potatoes", "slow cooker"},
+		Cost: &models.RecipeCost{
+			Recipe:      &models.Money{Cents: 250, Currency: "USD"},
+			Ingredients: &models.Money{Cents: 185, Currency: "USD"},
+			Mismatch:    true,
+		},
+		Nutrition: &models.Nutrition{
+			Calories:      &models.Measure{Value: 269, Unit: "kcal"},
+			Carbohydrates: &models.Measure{Value: 39, Unit: "g"},
+			Sodium:        &models.Measure{Value: 1180, Unit: "mg"},
+		},
+	}
+	assert(t, got.Recipe, want)
+}
+
+// Fields that can't be parsed are left empty and reported, rather than filled with error text.
+func TestWarnings(t *testing.T) {
+	card := `<div class="wprm-recipe-container" data-recipe-id="1">
+<h2 class="wprm-recipe-name wprm-block-text-bold"></h2>
+<div class="wprm-recipe-image"><img src="potatoes.jpg"></div>
+<ul class="wprm-recipe-ingredients">
+<li class="wprm-recipe-ingredient"><span class="wprm-recipe-ingredient-amount"></span><span class="wprm-recipe-ingredient-name">potatoes</span></li>
+</ul>
+<ul class="wprm-recipe-instructions"><li class="wprm-recipe-instruction"></li><li class="wprm-recipe-instruction">Boil.</li></ul>
+</div>`
+	doc, err := html.Parse(strings.NewReader(card))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	got, err := FromHTML(doc)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if got.Recipe.Name != "" || got.Recipe.Image != "" {
+		t.Errorf("got name %q and image %q, want both empty", got.Recipe.Name, got.Recipe.Image)
+	}
+	if !cmp.Equal(got.Recipe.Instructions, []string{"Boil."}) {
+		t.Errorf("got instructions %v, want [Boil.]", got.Recipe.Instructions)
+	}
+	want := []Warning{
+		{EmptyText, "name", "recipe name header has no text"},
+		{UnexpectedStructure, "image", "recipe image has no data-pin-media attribute"},
+		{EmptyText, "ingredients[0][0].amount", ""},
+		{EmptyText, "instructions[0]", "instruction has no text"},
+		{MissingField, "url", ""},
+	}
+	if !cmp.Equal(got.Warnings, want) {
+		t.Errorf("got warnings %v, want %v", got.Warnings, want)
+	}
+
+	_, err = FromHTML(doc, Strict())
+	extractErr, ok := err.(*ExtractionError)
+	if !ok {
+		t.Fatalf("got error %v, want *ExtractionError", err)
+	}
+	fields := []string{"name", "image", "ingredients[0][0].amount", "instructions[0]", "url"}
+	if !cmp.Equal(extractErr.Fields(), fields) {
+		t.Errorf("got fields %v, want %v", extractErr.Fields(), fields)
+	}
+}
+
+// Budgetbytes puts the price of each ingredient in its notes.
+func TestSplitCost(t *testing.T) {
+	tests := []struct {
+		notes, rest string
+		cents       int64
+	}{
+		{"($1.80)", "", 180},
+		{"($0.13*)", "", 13},
+		{"(about 1 cup, $0.25)", "(about 1 cup)", 25},
+		{"($0.50, divided)", "(divided)", 50},
+		{"$1,200.5", "", 120050},
+		{"divided", "divided", 0},
+		{"", "", 0},
 	}
 
 	for _, test := range tests {
-		rc := initTest(t, "./test-data/responses/"+test+".html")
-		if rc == nil {
-			t.Error("Error: couldn't find recipe card")
-			return
+		cost, rest := splitCost(test.notes)
+		if rest != test.rest {
+			t.Errorf("splitCost(%q) left notes %q, want %q", test.notes, rest, test.rest)
+		}
+		var cents int64
+		if cost != nil {
+			cents = cost.Cents
+		}
+		if cents != test.cents {
+			t.Errorf("splitCost(%q) = %d cents, want %d", test.notes, cents, test.cents)
 		}
-		t.Run(
-			test, func(t *testing.T) {
-				got, err := FromHTML(rc)
-				if err != nil {
-					t.Error("Error:", err)
-					return
-				}
-				want, err := FromJSON("./test-data/solutions/" + test + ".json")
-				if err != nil {
-					t.Error("Error:", err)
-					return
-				}
-				assert(t, got, want)
-			},
-		)
 	}
 }
 
-func TestMultipleIngredientLists(t *testing.T) {
-	tests := []string{
-		"beef-cabbage-stir-fry",
-		"chili-roasted-potatoes",
-		"fluffy-garlic-herb-mashed-potatoes",
+// Recipes without a card get an ID made from their URL, rather than the JSON-LD @id.
+func TestURLID(t *testing.T) {
+	tests := []struct {
+		source, want string
+	}{
+		{"https://www.budgetbytes.com/slow-cooker-mashed-potatoes/", "budgetbytes.com-slow-cooker-mashed-potatoes"},
+		{"https://www.budgetbytes.com/slow-cooker-mashed-potatoes/#recipe", "budgetbytes.com-slow-cooker-mashed-potatoes"},
+		{"https://Example.com/recipes/One_Pot%20Soup?page=2", "example.com-recipes-one-pot-soup"},
+		{"https://example.com", "example.com"},
+		{"#recipe", ""},
+		{"", ""},
 	}
 
 	for _, test := range tests {
-		rc := initTest(t, "./test-data/responses/"+test+".html")
-		if rc == nil {
-			t.Error("Error: couldn't find recipe card")
-			return
+		if got := urlID(test.source); got != test.want {
+			t.Errorf("urlID(%q) = %q, want %q", test.source, got, test.want)
 		}
-		t.Run(
-			test, func(t *testing.T) {
-				got, err := FromHTML(rc)
-				if err != nil {
-					t.Error("Error:", err)
-					return
-				}
-				want, err := FromJSON("./test-data/solutions/" + test + ".json")
-				if err != nil {
-					t.Error("Error:", err)
-					return
-				}
-				assert(t, got, want)
-			},
-		)
 	}
 }
@@ -0,0 +1,159 @@
+package recipe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/parser"
+	"golang.org/x/net/html"
+)
+
+// WarningKind describes what went wrong while extracting a field.
+type WarningKind string
+
+const (
+	// MissingField means the field couldn't be found on the page at all.
+	MissingField WarningKind = "missing field"
+	// EmptyText means the element for the field was found, but it had no text.
+	EmptyText WarningKind = "empty text"
+	// UnexpectedStructure means the element was found, but didn't look the way the extractor expected.
+	UnexpectedStructure WarningKind = "unexpected structure"
+)
+
+// Warning is a non-fatal problem found while extracting a recipe. The field it refers to is left
+// empty (or the item is skipped) rather than filled with placeholder text.
+type Warning struct {
+	Kind WarningKind `json:"kind"`
+	// Field is the JSON path of the affected field, eg. "image" or "ingredients[0][2].name".
+	Field   string `json:"field"`
+	Message string `json:"message,omitempty"`
+}
+
+func (w Warning) String() string {
+	if w.Message == "" {
+		return w.Field + ": " + string(w.Kind)
+	}
+	return w.Field + ": " + string(w.Kind) + ": " + w.Message
+}
+
+// ExtractionError is returned in strict mode when any warnings were raised.
+type ExtractionError struct {
+	Warnings []Warning
+}
+
+func (e *ExtractionError) Error() string {
+	list := make([]string, len(e.Warnings))
+	for i, w := range e.Warnings {
+		list[i] = w.String()
+	}
+	return fmt.Sprintf("recipe: %d field(s) failed to extract: %s", len(e.Warnings), strings.Join(list, "; "))
+}
+
+// Fields returns the name of every field that failed to extract.
+func (e *ExtractionError) Fields() []string {
+	fields := make([]string, len(e.Warnings))
+	for i, w := range e.Warnings {
+		fields[i] = w.Field
+	}
+	return fields
+}
+
+// Option configures how FromHTML extracts a recipe.
+type Option func(*options)
+
+type options struct {
+	strict     bool
+	markdown   bool
+	strategies []Strategy
+}
+
+// Strict makes FromHTML return an *ExtractionError instead of a result if any warnings are raised.
+func Strict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// Markdown keeps the links, bold and italics in the instructions and summary from the recipe card
+// as Markdown (eg. "Add the [garlic](https://...)"), rather than keeping only their text.
+func Markdown() Option {
+	return func(o *options) {
+		o.markdown = true
+	}
+}
+
+// Strategies limits FromHTML to the given extractors. By default, every extractor is tried.
+func Strategies(s ...Strategy) Option {
+	return func(o *options) {
+		o.strategies = s
+	}
+}
+
+// text returns the function that reads the text of the fields that may be Markdown.
+func (o *options) text() func(*html.Node) string {
+	if o.markdown {
+		return parser.GetMarkdown
+	}
+	return parser.GetText
+}
+
+func (o *options) uses(s Strategy) bool {
+	if o.strategies == nil {
+		return true
+	}
+	for _, t := range o.strategies {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+// warnings collects the problems found by an extractor.
+type warnings []Warning
+
+func (w *warnings) add(kind WarningKind, field, message string) {
+	*w = append(*w, Warning{Kind: kind, Field: field, Message: message})
+}
+
+// drop removes any warnings about the given top-level fields, eg. once they have been backfilled.
+func (w *warnings) drop(fields ...string) {
+	kept := (*w)[:0]
+	for _, warning := range *w {
+		dropped := false
+		for _, field := range fields {
+			if warning.Field == field || strings.HasPrefix(warning.Field, field+"[") {
+				dropped = true
+			}
+		}
+		if !dropped {
+			kept = append(kept, warning)
+		}
+	}
+	*w = kept
+}
+
+// checkRequired adds a MissingField warning for each required field that is still empty, unless
+// a more specific warning was already raised for it.
+func (w *warnings) checkRequired(rcp *models.Recipe) {
+	has := func(field string) bool {
+		for _, warning := range *w {
+			if warning.Field == field {
+				return true
+			}
+		}
+		return false
+	}
+	check := func(field string, empty bool) {
+		if empty && !has(field) {
+			w.add(MissingField, field, "")
+		}
+	}
+	check("id", rcp.ID == "")
+	check("name", rcp.Name == "")
+	check("url", rcp.URL == "")
+	check("image", rcp.Image == "")
+	check("ingredients", len(rcp.AllIngredients()) == 0)
+	check("instructions", len(rcp.Instructions) == 0)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/reparse"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+func init() {
+	commands["reparse"] = command{
+		usage: "recipe-parser reparse [-json] [-update] [-strict] [-dbpath dir]",
+		run:   runReparse,
+	}
+}
+
+// runReparse parses every saved recipe again from the snapshot of its page, and reports the ones
+// that would change. Nothing is downloaded.
+func runReparse(args []string) error {
+	fs := flag.NewFlagSet("reparse", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "write a JSON Lines report of every recipe instead of a summary of the changes")
+	update := fs.Bool("update", false, "replace the saved recipes that changed")
+	strict := fs.Bool("strict", false, "fail any recipe with a field that can't be parsed")
+	dbPath := dbFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return errors.New("usage: recipe-parser reparse [flags]")
+	}
+
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close(db)
+
+	r := &reparse.Reparser{Store: db, Update: *update}
+	if *strict {
+		r.Options = append(r.Options, recipe.Strict())
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	counts := make(map[reparse.Status]int)
+	err = r.Run(ctx, func(e reparse.Entry) {
+		counts[e.Status]++
+		if *asJSON {
+			if err := enc.Encode(e); err != nil {
+				log.Println("Error: writing report:", err)
+			}
+			return
+		}
+		switch e.Status {
+		case reparse.Changed, reparse.Updated:
+			fmt.Printf("%s %s: %s\n", e.Status, e.ID, strings.Join(e.Fields, ", "))
+		case reparse.Failed:
+			log.Println("Error:", e.ID+":", e.Error)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("%d unchanged, %d changed, %d updated, %d failed, %d without a snapshot",
+		counts[reparse.Unchanged], counts[reparse.Changed], counts[reparse.Updated], counts[reparse.Failed], counts[reparse.NoSnapshot])
+	if counts[reparse.Failed] > 0 {
+		return fmt.Errorf("%d recipes failed", counts[reparse.Failed])
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package reparse parses stored recipes again from the snapshots of their pages, to see what a
+// change to the parser would do to them without downloading anything.
+package reparse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"golang.org/x/net/html"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"github.com/ejacobg/recipe-parser/sites"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+// ErrNoSnapshots is returned for stores that don't keep snapshots, eg. MongoDB.
+var ErrNoSnapshots = errors.New("store doesn't keep snapshots of recipe pages")
+
+// Status is the outcome for one stored recipe.
+type Status string
+
+const (
+	// Unchanged means the page parses to the stored recipe.
+	Unchanged Status = "unchanged"
+	// Changed means the page now parses to a different recipe. See Entry.Fields.
+	Changed Status = "changed"
+	// Updated means the recipe changed, and the stored copy was replaced (see Reparser.Update).
+	Updated Status = "updated"
+	// Failed means the page no longer parses, or the new recipe couldn't be saved. See Entry.Error.
+	Failed Status = "failed"
+	// NoSnapshot means the recipe's page wasn't kept, eg. it was saved before snapshots were.
+	NoSnapshot Status = "no-snapshot"
+)
+
+// Entry is one line of the report, describing what happened to one stored recipe.
+type Entry struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Status Status `json:"status"`
+	// Fields lists the (JSON) names of the top-level fields that would change.
+	Fields   []string         `json:"fields,omitempty"`
+	Warnings []recipe.Warning `json:"warnings,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// Reparser parses every recipe in Store again.
+type Reparser struct {
+	Store store.RecipeStore
+	// Options are passed on to the parser, eg. recipe.Strict().
+	Options []recipe.Option
+	// Update replaces the stored recipes that changed with the new ones.
+	Update bool
+}
+
+// Run reparses every stored recipe, calling report once for each. Only the error from reading
+// the store is returned, problems with single recipes are reported instead.
+func (r *Reparser) Run(ctx context.Context, report func(Entry)) error {
+	snapshots, ok := r.Store.(store.SnapshotStore)
+	if !ok {
+		return ErrNoSnapshots
+	}
+	rcps, err := r.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(rcps, func(i, j int) bool { return rcps[i].ID < rcps[j].ID })
+	for _, rcp := range rcps {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		report(r.do(ctx, snapshots, rcp))
+	}
+	return nil
+}
+
+// do reparses a single recipe.
+func (r *Reparser) do(ctx context.Context, snapshots store.SnapshotStore, old *models.Recipe) Entry {
+	entry := Entry{ID: old.ID, Name: old.Name, URL: old.URL}
+	fail := func(err error) Entry {
+		entry.Status, entry.Error = Failed, err.Error()
+		return entry
+	}
+
+	snap, err := snapshots.GetSnapshot(ctx, old)
+	if err == store.ErrNoSnapshot {
+		entry.Status = NoSnapshot
+		return entry
+	} else if err != nil {
+		return fail(err)
+	}
+	res, err := Parse(snap, r.Options...)
+	if err != nil {
+		return fail(err)
+	}
+	entry.Warnings = res.Warnings
+
+	if entry.Fields, err = Diff(old, res.Recipe); err != nil {
+		return fail(err)
+	}
+	if len(entry.Fields) == 0 {
+		entry.Status = Unchanged
+		return entry
+	}
+	entry.Status = Changed
+	if !r.Update {
+		return entry
+	}
+
+	// A recipe whose ID changed takes the place of the old one.
+	if err = store.SaveAs(ctx, r.Store, old.ID, res.Recipe, snap); err != nil {
+		return fail(err)
+	}
+	entry.Status = Updated
+	return entry
+}
+
+// Parse parses the page kept in a snapshot, the same way it was parsed when it was downloaded.
+func Parse(snap *models.Snapshot, opts ...recipe.Option) (*recipe.Result, error) {
+	doc, err := html.Parse(bytes.NewReader(snap.Body))
+	if err != nil {
+		return nil, err
+	}
+	return sites.Parse(snap.URL, doc, opts...)
+}
+
+// Diff returns the JSON names of the top-level fields that differ between two recipes, sorted.
+func Diff(old, new *models.Recipe) ([]string, error) {
+	a, err := fields(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fields(new)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for name, value := range a {
+		if other, ok := b[name]; !ok || !bytes.Equal(value, other) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// fields splits a recipe's JSON into its top-level fields.
+func fields(rcp *models.Recipe) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(rcp)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	return m, json.Unmarshal(data, &m)
+}
@@ -0,0 +1,173 @@
+package reparse
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+const page = `<html><head><script type="application/ld+json">
+{"@context": "https://schema.org", "@type": "Recipe", "@id": "https://example.com/lentil-soup/#recipe", "name": "Lentil Soup",
+ "url": "https://example.com/lentil-soup/", "recipeIngredient": ["1 cup brown lentils"],
+ "recipeInstructions": [{"@type": "HowToStep", "text": "Cook it."}]}
+</script></head><body></body></html>`
+
+// saveSnapshot parses the page and saves the recipe along with the page, as the fetch would.
+func saveSnapshot(t *testing.T, s store.RecipeStore, source, body string) *models.Recipe {
+	t.Helper()
+	snap := models.NewSnapshot(source, time.Now().UTC(), http.StatusOK, nil, []byte(body))
+	res, err := Parse(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Save(context.Background(), s, res.Recipe, snap); err != nil {
+		t.Fatal(err)
+	}
+	return res.Recipe
+}
+
+func run(t *testing.T, r *Reparser) map[string]Entry {
+	t.Helper()
+	entries := make(map[string]Entry)
+	if err := r.Run(context.Background(), func(e Entry) { entries[e.ID] = e }); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	s, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// This one is stored as it was parsed, then edited as if an older parser got the name wrong.
+	soup := saveSnapshot(t, s, "https://example.com/lentil-soup/", page)
+	soup.Name = "Lentil"
+	soup.Instructions = nil
+	if err = s.Replace(ctx, soup); err != nil {
+		t.Fatal(err)
+	}
+	// This one was saved before snapshots were kept.
+	if err = s.Insert(ctx, &models.Recipe{ID: "stew", Name: "Stew", URL: "https://example.com/stew/"}); err != nil {
+		t.Fatal(err)
+	}
+	// This one's page no longer has a recipe the parser can find.
+	pie := &models.Recipe{ID: "pie", Name: "Pie", URL: "https://example.com/pie/"}
+	if err = s.Insert(ctx, pie); err != nil {
+		t.Fatal(err)
+	}
+	snap := models.NewSnapshot(pie.URL, time.Now().UTC(), http.StatusOK, nil, []byte("<html><body>Members only</body></html>"))
+	if err = s.SaveSnapshot(ctx, pie, snap); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := run(t, &Reparser{Store: s})
+	if got := entries[soup.ID]; got.Status != Changed || !cmp.Equal(got.Fields, []string{"instructions", "name"}) {
+		t.Errorf("soup: got %+v, want changed name and instructions", got)
+	}
+	if got := entries["stew"]; got.Status != NoSnapshot {
+		t.Errorf("stew: got %+v, want %s", got, NoSnapshot)
+	}
+	if got := entries["pie"]; got.Status != Failed || got.Error == "" {
+		t.Errorf("pie: got %+v, want %s", got, Failed)
+	}
+	if stored, _ := s.GetByID(ctx, soup.ID); stored.Name != "Lentil" {
+		t.Errorf("recipe was replaced without -update: %+v", stored)
+	}
+
+	entries = run(t, &Reparser{Store: s, Update: true})
+	if got := entries[soup.ID]; got.Status != Updated {
+		t.Errorf("soup: got %+v, want %s", got, Updated)
+	}
+	if stored, _ := s.GetByID(ctx, soup.ID); stored.Name != "Lentil Soup" || len(stored.Instructions) != 1 {
+		t.Errorf("recipe wasn't replaced: %+v", stored)
+	}
+
+	entries = run(t, &Reparser{Store: s})
+	if got := entries[soup.ID]; got.Status != Unchanged {
+		t.Errorf("soup after update: got %+v, want %s", got, Unchanged)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := &models.Recipe{ID: "a", Name: "Soup", Servings: 4}
+	new := &models.Recipe{ID: "a", Name: "Soup", Author: "Beth"}
+	got, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"author", "servings"}; !cmp.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// A recipe that the parser now gives another ID takes the place of the old one, and is left alone
+// if it can't.
+func TestRunChangedID(t *testing.T) {
+	dir, err := store.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := store.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close(db) })
+
+	for name, s := range map[string]store.RecipeStore{"dir": dir, "sqlite": db} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			snapshots := s.(store.SnapshotStore)
+			old := &models.Recipe{ID: "old", Name: "Lentil", URL: "https://example.com/lentil-soup/"}
+			if err := s.Insert(ctx, old); err != nil {
+				t.Fatal(err)
+			}
+			snap := models.NewSnapshot(old.URL, time.Now().UTC(), http.StatusOK, nil, []byte(page))
+			if err := snapshots.SaveSnapshot(ctx, old, snap); err != nil {
+				t.Fatal(err)
+			}
+			// another recipe already has the new ID
+			taken := &models.Recipe{ID: "example.com-lentil-soup", Name: "Other", URL: "https://example.com/other/"}
+			if err := s.Insert(ctx, taken); err != nil {
+				t.Fatal(err)
+			}
+
+			entries := run(t, &Reparser{Store: s, Update: true})
+			if got := entries["old"]; got.Status != Failed {
+				t.Errorf("got %+v, want %s", got, Failed)
+			}
+			if stored, err := s.GetByID(ctx, "old"); err != nil || stored.Name != "Lentil" {
+				t.Fatalf("old recipe = %+v, %v, want it left alone", stored, err)
+			}
+			if _, err := snapshots.GetSnapshot(ctx, old); err != nil {
+				t.Errorf("old snapshot: %v", err)
+			}
+
+			if err := s.Delete(ctx, taken.ID); err != nil {
+				t.Fatal(err)
+			}
+			entries = run(t, &Reparser{Store: s, Update: true})
+			if got := entries["old"]; got.Status != Updated {
+				t.Errorf("got %+v, want %s", got, Updated)
+			}
+			if _, err := s.GetByID(ctx, "old"); err != store.ErrNotFound {
+				t.Errorf("old recipe is still stored: %v", err)
+			}
+			stored, err := s.GetByID(ctx, taken.ID)
+			if err != nil || stored.Name != "Lentil Soup" {
+				t.Fatalf("new recipe = %+v, %v", stored, err)
+			}
+			if _, err = snapshots.GetSnapshot(ctx, stored); err != nil {
+				t.Errorf("new snapshot: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+)
+
+func init() {
+	commands["scale"] = command{
+		usage: "recipe-parser scale [-servings n | -factor x] [-units system] [-dbpath dir] <recipe-name|recipe-url|file.json>",
+		run:   scale,
+	}
+}
+
+// scale prints the recipe scaled to a number of servings or by a factor.
+func scale(args []string) error {
+	fs := flag.NewFlagSet("scale", flag.ExitOnError)
+	servings := fs.Float64("servings", 0, "scale the recipe so it makes this many servings")
+	factor := fs.Float64("factor", 0, "multiply the recipe by this amount, eg. 2 to double it")
+	system := fs.String("units", "", "convert ingredient amounts to \"metric\" or \"us\" units")
+	dbPath := dbFlag(fs)
+	setupFetch := fetchFlags(fs)
+	fs.Parse(args)
+	if err := setupFetch(); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("scale takes exactly one recipe")
+	}
+	if (*servings == 0) == (*factor == 0) {
+		return errors.New("give either -servings or -factor")
+	}
+
+	r, err := loadRecipe(fs.Arg(0), *dbPath)
+	if err != nil {
+		return err
+	}
+	if *servings != 0 {
+		err = r.ScaleToServings(*servings)
+	} else {
+		err = r.Scale(*factor)
+	}
+	if err != nil {
+		return err
+	}
+	if err = convertUnits(r, *system); err != nil {
+		return err
+	}
+	return printRecipe(r)
+}
@@ -1,13 +1,62 @@
+// Command server runs the recipe API locally, using a directory of recipe JSON files (see store.Dir)
+// or a SQLite database (see store.SQLite) instead of MongoDB. Every route is served: /api/recipe and
+// /api/data, searches through /api/recipes and /api/search, /api/pantry, /api/shopping and /api/plans. When
+// using a directory, the saved files themselves can be browsed under /database/.
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+
+	utils "github.com/ejacobg/recipe-parser/api-utils"
+	"github.com/ejacobg/recipe-parser/fetch"
+	"github.com/ejacobg/recipe-parser/store"
+)
+
+var (
+	addr    = flag.String("addr", "localhost:8080", "address to listen on")
+	dbPath  = flag.String("dbpath", "./database/", "directory of saved recipes, or a SQLite database (.db)")
+	cache   = flag.String("cache", "", "keep downloaded pages in this directory, and only download them again if they've changed")
+	offline = flag.Bool("offline", false, "only read pages from the -cache directory, never from the network")
 )
 
 func main() {
+	flag.Parse()
+	if *cache != "" {
+		fetch.Default.Cache = &fetch.Cache{Dir: *cache}
+	}
+	if *offline {
+		if *cache == "" {
+			log.Fatalln("Error: -offline needs a -cache directory")
+		}
+		fetch.Default.Offline = true
+	}
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		log.Fatalln("Error:", err)
+	}
+	defer store.Close(db)
+
+	handler := utils.NewHandler(db)
+	http.Handle("/api/recipe", handler)
+	http.Handle("/api/data", handler)
+	http.Handle("/api/pantry", &utils.PantryHandler{Store: db})
+	http.Handle("/api/shopping", &utils.ShoppingHandler{Store: db})
+	if searcher, ok := db.(store.Searcher); ok {
+		search := &utils.SearchHandler{Store: searcher}
+		http.Handle("/api/recipes", search)
+		http.Handle("/api/search", search)
+	}
+	if plans, ok := db.(utils.PlanStore); ok {
+		http.Handle("/api/plans", &utils.PlanHandler{Store: plans})
+	}
 	// https://pkg.go.dev/net/http#example-FileServer
-	fmt.Println("Serving on http://localhost:8080/")
-	log.Fatal(http.ListenAndServe(":8080", http.FileServer(http.Dir("./recipes"))))
+	if _, ok := db.(*store.Dir); ok {
+		http.Handle("/database/", http.StripPrefix("/database/", http.FileServer(http.Dir(*dbPath))))
+	}
+
+	fmt.Println("Serving on http://" + *addr + "/")
+	log.Fatal(http.ListenAndServe(*addr, nil))
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/shopping"
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+func init() {
+	commands["shop"] = command{
+		usage: "recipe-parser shop [-format text|markdown|json] [-units system] [-dbpath dir] <recipe>[:factor|@servings]...",
+		run:   shop,
+	}
+}
+
+// shop prints a shopping list for the recipes. Each recipe can be scaled by a factor (eg.
+// "chili:2") or to a number of servings (eg. "chili@8").
+func shop(args []string) error {
+	fs := flag.NewFlagSet("shop", flag.ExitOnError)
+	format := fs.String("format", "text", "write the list as \"text\", \"markdown\" or \"json\"")
+	system := fs.String("units", "", "give amounts in \"metric\" or \"us\" units")
+	dbPath := dbFlag(fs)
+	setupFetch := fetchFlags(fs)
+	fs.Parse(args)
+	if err := setupFetch(); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New("no recipes given")
+	}
+	f, err := shopping.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	sys := units.None
+	if *system != "" {
+		if sys, err = units.ParseSystem(*system); err != nil {
+			return err
+		}
+	}
+
+	var recipes []*models.Recipe
+	for _, arg := range fs.Args() {
+		pick, err := shopping.ParsePick(arg)
+		if err != nil {
+			return err
+		}
+		r, err := loadRecipe(pick.Recipe, *dbPath)
+		if err != nil {
+			return err
+		}
+		if err = pick.Apply(r); err != nil {
+			return errors.New(pick.Recipe + ": " + err.Error())
+		}
+		recipes = append(recipes, r)
+	}
+	return shopping.New(recipes, sys).Write(os.Stdout, f)
+}
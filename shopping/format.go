@@ -0,0 +1,120 @@
+package shopping
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is a way of writing out a shopping list.
+type Format string
+
+const (
+	Text     Format = "text"
+	Markdown Format = "markdown"
+	JSON     Format = "json"
+)
+
+// ParseFormat reads a format name as used by the CLI and API. "md" is short for "markdown".
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "", Text:
+		return Text, nil
+	case Markdown, "md":
+		return Markdown, nil
+	case JSON:
+		return JSON, nil
+	}
+	return "", errors.New(`unknown format "` + s + `", use "text", "markdown" or "json"`)
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	case JSON:
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Write writes the list in the given format.
+func (l *List) Write(w io.Writer, f Format) error {
+	switch f {
+	case JSON:
+		data, err := json.MarshalIndent(l, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case Markdown:
+		_, err := io.WriteString(w, l.markdown())
+		return err
+	}
+	_, err := io.WriteString(w, l.text())
+	return err
+}
+
+// line writes an item as a line of the list, eg. "3 lb. russet potato ($1.80)".
+func (it *Item) line() string {
+	var amounts []string
+	for _, a := range it.Amounts {
+		amounts = append(amounts, a.String())
+	}
+	line := it.Name
+	if len(amounts) > 0 {
+		line = strings.Join(amounts, " + ") + " " + line
+	}
+	if it.Cost != nil {
+		line += " (" + it.Cost.String() + ")"
+	}
+	return line
+}
+
+// costLine sums up the cost of the list, eg. "Estimated cost: $4.20 (2 items without a price)".
+func (l *List) costLine() string {
+	line := "Estimated cost: unknown"
+	if l.Cost != nil {
+		line = "Estimated cost: " + l.Cost.String()
+	}
+	switch {
+	case l.Unpriced == 1:
+		line += " (1 item without a price)"
+	case l.Unpriced > 1:
+		line += fmt.Sprintf(" (%d items without a price)", l.Unpriced)
+	}
+	return line
+}
+
+func (l *List) text() string {
+	var b strings.Builder
+	b.WriteString("Shopping list for " + strings.Join(l.Recipes, ", ") + "\n")
+	for _, s := range l.Sections {
+		b.WriteString("\n" + s.Name + "\n")
+		for _, it := range s.Items {
+			b.WriteString("  " + it.line() + "\n")
+		}
+	}
+	b.WriteString("\n" + l.costLine() + "\n")
+	return b.String()
+}
+
+func (l *List) markdown() string {
+	var b strings.Builder
+	b.WriteString("# Shopping list\n\n")
+	for _, name := range l.Recipes {
+		b.WriteString("- " + name + "\n")
+	}
+	for _, s := range l.Sections {
+		b.WriteString("\n## " + s.Name + "\n\n")
+		for _, it := range s.Items {
+			b.WriteString("- [ ] " + it.line() + "\n")
+		}
+	}
+	b.WriteString("\n" + l.costLine() + "\n")
+	return b.String()
+}
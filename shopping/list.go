@@ -0,0 +1,280 @@
+// Package shopping builds a shopping list from several recipes, combining the ingredients that
+// they share.
+package shopping
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/units"
+)
+
+// List is a shopping list, with its items grouped by store section.
+type List struct {
+	// Recipes are the names of the recipes the list was made from.
+	Recipes  []string  `json:"recipes"`
+	Sections []Section `json:"sections"`
+	// Cost is the total price of the items that have one. It is nil if no item has a price, or if
+	// the prices use different currencies.
+	Cost *models.Money `json:"cost,omitempty"`
+	// Unpriced is the number of items without a price, which aren't included in Cost.
+	Unpriced int `json:"unpriced"`
+}
+
+// Section is a part of the store, eg. "Produce", and the items to buy there.
+type Section struct {
+	Name  string `json:"name"`
+	Items []Item `json:"items"`
+}
+
+// Item is an ingredient to buy, combined across every recipe that uses it.
+type Item struct {
+	// Name is the normalized name of the ingredient (see models.NormalizeName).
+	Name string `json:"name"`
+	// Amounts is how much to buy. Amounts in units that can be converted into each other (eg. cups
+	// and tablespoons) are added up into one, but there is an amount for every other unit (eg. "2
+	// cloves" and "1 tsp" of garlic). It is empty if no recipe gives an amount.
+	Amounts []Amount `json:"amounts"`
+	// Cost is the total of the ingredient's prices across the recipes, if any of them give one.
+	Cost *models.Money `json:"cost,omitempty"`
+	// Recipes are the names of the recipes that use the ingredient.
+	Recipes []string `json:"recipes"`
+}
+
+// Amount is an amount of an ingredient, written the same way as on a recipe.
+type Amount struct {
+	Amount string `json:"amount"`
+	Unit   string `json:"unit"`
+}
+
+func (a Amount) String() string {
+	return strings.TrimSpace(a.Amount + " " + a.Unit)
+}
+
+// total is the running total of an item's amounts in one unit (or dimension).
+type total struct {
+	value, max float64    // max equals value unless one of the amounts was a range
+	unit       string     // the unit as first written, for counts and unknown units
+	measured   units.Unit // the base unit of masses and volumes, see base
+	text       string     // an amount that isn't a number, eg. "to taste"
+}
+
+type item struct {
+	Item
+	totals []*total
+	keys   map[string]*total
+	// the system of the first measured amount, which the total is given in unless another system
+	// is asked for
+	system units.System
+	mixed  bool // prices use different currencies
+}
+
+// New builds a shopping list from the recipes, which should already be scaled as needed.
+// Masses and volumes are given in the system of measurement that the recipes use, unless another
+// system is given.
+func New(recipes []*models.Recipe, system units.System) *List {
+	list := &List{Recipes: []string{}, Sections: []Section{}}
+	items := make(map[string]*item)
+	var order []string
+	for _, rcp := range recipes {
+		list.Recipes = append(list.Recipes, rcp.Name)
+		for _, i := range rcp.AllIngredients() {
+			name := i.NormalizedName()
+			if name == "" {
+				continue
+			}
+			it, ok := items[name]
+			if !ok {
+				it = &item{Item: Item{Name: name, Amounts: []Amount{}, Recipes: []string{}}, keys: make(map[string]*total)}
+				items[name] = it
+				order = append(order, name)
+			}
+			it.add(i, rcp.Name)
+		}
+	}
+
+	sections := make(map[string]*Section)
+	var cost *models.Money
+	mixed := false
+	for _, name := range order {
+		it := items[name]
+		out := it.finish(system)
+		switch {
+		case out.Cost == nil:
+			list.Unpriced++
+		case cost == nil:
+			cost = &models.Money{Cents: out.Cost.Cents, Currency: out.Cost.Currency}
+		case cost.Currency != out.Cost.Currency:
+			mixed = true
+		default:
+			cost.Cents += out.Cost.Cents
+		}
+
+		section := SectionOf(name)
+		if sections[section] == nil {
+			sections[section] = &Section{Name: section}
+		}
+		sections[section].Items = append(sections[section].Items, out)
+	}
+	if !mixed {
+		list.Cost = cost
+	}
+
+	for _, name := range sectionOrder {
+		if s, ok := sections[name]; ok {
+			sort.SliceStable(s.Items, func(i, j int) bool { return s.Items[i].Name < s.Items[j].Name })
+			list.Sections = append(list.Sections, *s)
+		}
+	}
+	return list
+}
+
+// add adds one recipe's use of the ingredient to the item.
+func (it *item) add(i models.Ingredient, recipe string) {
+	if n := len(it.Recipes); n == 0 || it.Recipes[n-1] != recipe {
+		it.Recipes = append(it.Recipes, recipe)
+	}
+	if i.Cost != nil {
+		switch {
+		case it.Cost == nil:
+			it.Cost = &models.Money{Currency: i.Cost.Currency}
+		case it.Cost.Currency != i.Cost.Currency:
+			it.mixed = true
+		}
+		it.Cost.Cents += i.Cost.Cents
+	}
+
+	if i.Quantity == nil {
+		i.ParseAmount()
+	}
+	q := i.Quantity
+	switch {
+	case q == nil && i.Amount == "":
+		// no amount, eg. "salt"
+	case q == nil:
+		// an amount that couldn't be read is kept as written
+		it.total("text:"+strings.ToLower(i.Amount+" "+i.Unit), i.Unit).text = i.Amount
+	case q.ToTaste:
+		it.total("to taste", "").text = "to taste"
+	default:
+		max := q.Value
+		if q.IsRange() {
+			max = q.Max
+		}
+		if u, ok := units.Lookup(i.Unit); ok && u.Dimension != units.Count {
+			if it.system == units.None {
+				it.system = u.System
+			}
+			t := it.total(u.Dimension.String(), "")
+			t.measured = base(u.Dimension)
+			t.value += q.Value * u.Base
+			t.max += max * u.Base
+		} else {
+			// counts and unknown units are added up with the same unit, eg. "cloves" and "clove"
+			t := it.total("count:"+models.NormalizeName(i.Unit), i.Unit)
+			t.value += q.Value
+			t.max += max
+		}
+	}
+}
+
+func (it *item) total(key, unit string) *total {
+	t, ok := it.keys[key]
+	if !ok {
+		t = &total{unit: unit}
+		it.keys[key] = t
+		it.totals = append(it.totals, t)
+	}
+	return t
+}
+
+// base returns the unit that masses or volumes are added up in.
+func base(d units.Dimension) units.Unit {
+	if d == units.Mass {
+		return units.Gram
+	}
+	return units.Millilitre
+}
+
+// finish writes out the item's totals, converting masses and volumes to the system (or the item's
+// own system if None).
+func (it *item) finish(system units.System) Item {
+	if system == units.None {
+		system = it.system
+	}
+	if it.mixed {
+		it.Cost = nil
+	}
+	for _, t := range it.totals {
+		if t.text != "" {
+			it.Amounts = append(it.Amounts, Amount{t.text, t.unit})
+			continue
+		}
+		q := models.Quantity{Value: t.value}
+		if t.max > t.value {
+			q.Max = t.max
+		}
+		i := models.Ingredient{Unit: t.unit, Quantity: &q}
+		if t.measured != (units.Unit{}) {
+			// totals are kept in grams or millilitres, so they need moving to a readable unit even
+			// if they're in the right system already (eg. 1500 g is 1.5 kg), and rounding to
+			// something that can be measured once converted
+			i.Unit = t.measured.Symbol
+			i.ConvertUnits(system)
+			i.Rescale()
+		} else {
+			// rounds the amount and writes it out
+			i.Scale(1)
+		}
+		it.Amounts = append(it.Amounts, Amount{i.Amount, i.Unit})
+	}
+	return it.Item
+}
+
+// Pick is a recipe to shop for, and how much of it to make.
+type Pick struct {
+	// Recipe is the name, URL or ID of the recipe.
+	Recipe string
+	// Factor scales the recipe, and Servings scales it to make that many servings. At most one of
+	// them is set.
+	Factor, Servings float64
+}
+
+// ParsePick reads a recipe to shop for. A recipe may be followed by ":factor" to scale it (eg.
+// "slow-cooker-mashed-potatoes:2" doubles it), or "@servings" to make that many servings (eg.
+// "vegetarian-chili@8").
+func ParsePick(s string) (Pick, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexAny(s, ":@"); i > 0 {
+		if n, err := strconv.ParseFloat(s[i+1:], 64); err == nil {
+			if n <= 0 {
+				return Pick{}, errors.New("can't make " + s[i+1:] + " of " + s[:i])
+			}
+			p := Pick{Recipe: s[:i]}
+			if s[i] == ':' {
+				p.Factor = n
+			} else {
+				p.Servings = n
+			}
+			return p, nil
+		}
+	}
+	if s == "" {
+		return Pick{}, errors.New("no recipe given")
+	}
+	return Pick{Recipe: s}, nil
+}
+
+// Apply scales the recipe as picked.
+func (p Pick) Apply(rcp *models.Recipe) error {
+	switch {
+	case p.Factor > 0:
+		return rcp.Scale(p.Factor)
+	case p.Servings > 0:
+		return rcp.ScaleToServings(p.Servings)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package shopping
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/units"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testRecipe(name string, ingredients ...models.Ingredient) *models.Recipe {
+	return &models.Recipe{
+		Name:        name,
+		Servings:    4,
+		Ingredients: models.IngredientGroups{{Ingredients: ingredients}},
+	}
+}
+
+func usd(cents int64) *models.Money {
+	return &models.Money{Cents: cents, Currency: "USD"}
+}
+
+func TestNew(t *testing.T) {
+	toast := testRecipe("Garlic Toast",
+		models.Ingredient{Amount: "2", Unit: "Tbsp", Name: "butter", Cost: usd(25)},
+		models.Ingredient{Amount: "2", Unit: "cloves", Name: "garlic, minced", Cost: usd(16)},
+		models.Ingredient{Amount: "4", Unit: "slices", Name: "bread"},
+		models.Ingredient{Name: "salt to taste"},
+	)
+	chili := testRecipe("Vegetarian Chili",
+		models.Ingredient{Amount: "1/4", Unit: "cup", Name: "Butter", Cost: usd(50)},
+		models.Ingredient{Amount: "3", Unit: "clove", Name: "garlic"},
+		models.Ingredient{Amount: "1", Unit: "tsp", Name: "garlic"},
+		models.Ingredient{Amount: "15", Unit: "oz.", Name: "black beans", Cost: usd(89)},
+		models.Ingredient{Amount: "1-2", Unit: "", Name: "jalapeños"},
+	)
+	if err := (Pick{Recipe: "chili", Factor: 2}).Apply(chili); err != nil {
+		t.Fatal(err)
+	}
+
+	list := New([]*models.Recipe{toast, chili}, units.None)
+	got := make(map[string]Item)
+	var sections []string
+	for _, s := range list.Sections {
+		sections = append(sections, s.Name)
+		for _, it := range s.Items {
+			got[it.Name] = it
+		}
+	}
+	if want := []string{"Produce", "Dairy & Eggs", "Bakery", "Canned & Dry Goods", "Spices"}; !cmp.Equal(sections, want) {
+		t.Errorf("sections = %v, want %v", sections, want)
+	}
+
+	tests := []struct {
+		name    string
+		amounts []string
+		cost    *models.Money
+	}{
+		{"butter", []string{"5/8 cup"}, usd(125)}, // 2 Tbsp + 1/2 cup
+		{"garlic", []string{"8 cloves", "2 tsp"}, usd(16)},
+		{"black bean", []string{"1 7/8 lb."}, usd(178)},
+		{"jalapeño", []string{"2-4"}, nil},
+		{"bread", []string{"4 slices"}, nil},
+		{"salt", []string{"to taste"}, nil},
+	}
+	for _, test := range tests {
+		it, ok := got[test.name]
+		if !ok {
+			t.Errorf("%s isn't on the list", test.name)
+			continue
+		}
+		var amounts []string
+		for _, a := range it.Amounts {
+			amounts = append(amounts, a.String())
+		}
+		if !cmp.Equal(amounts, test.amounts) || !cmp.Equal(it.Cost, test.cost) {
+			t.Errorf("%s: got %v costing %v, want %v costing %v", test.name, amounts, it.Cost, test.amounts, test.cost)
+		}
+	}
+	if want := []string{"Garlic Toast", "Vegetarian Chili"}; !cmp.Equal(got["garlic"].Recipes, want) {
+		t.Errorf("garlic is used by %v, want %v", got["garlic"].Recipes, want)
+	}
+	if !cmp.Equal(list.Cost, usd(319)) || list.Unpriced != 3 {
+		t.Errorf("cost = %v with %d unpriced items, want $3.19 with 3", list.Cost, list.Unpriced)
+	}
+
+	var b bytes.Buffer
+	if err := list.Write(&b, Markdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"## Produce", "- [ ] 8 cloves + 2 tsp garlic ($0.16)", "Estimated cost: $3.19 (3 items without a price)"} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("markdown is missing %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestNewMetric(t *testing.T) {
+	list := New([]*models.Recipe{
+		testRecipe("A", models.Ingredient{Amount: "1", Unit: "lb", Name: "potatoes"}),
+		testRecipe("B", models.Ingredient{Amount: "500", Unit: "g", Name: "potato"}),
+	}, units.Metric)
+	if got := list.Sections[0].Items[0].Amounts; !cmp.Equal(got, []Amount{{"955", "g"}}) {
+		t.Errorf("potatoes = %v, want 955 g", got)
+	}
+}
+
+func TestParsePick(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Pick
+	}{
+		{"slow-cooker-mashed-potatoes", Pick{Recipe: "slow-cooker-mashed-potatoes"}},
+		{"slow-cooker-mashed-potatoes:2", Pick{Recipe: "slow-cooker-mashed-potatoes", Factor: 2}},
+		{"vegetarian-chili@8", Pick{Recipe: "vegetarian-chili", Servings: 8}},
+		{"https://www.budgetbytes.com/vegetarian-chili/", Pick{Recipe: "https://www.budgetbytes.com/vegetarian-chili/"}},
+		{"https://www.budgetbytes.com/vegetarian-chili/:0.5", Pick{Recipe: "https://www.budgetbytes.com/vegetarian-chili/", Factor: 0.5}},
+	}
+	for _, test := range tests {
+		got, err := ParsePick(test.s)
+		if err != nil || got != test.want {
+			t.Errorf("ParsePick(%q) = %+v, %v, want %+v", test.s, got, err, test.want)
+		}
+	}
+	for _, s := range []string{"", "chili:0", "chili@-1"} {
+		if _, err := ParsePick(s); err == nil {
+			t.Errorf("ParsePick(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package shopping
+
+import "strings"
+
+// Other is the section for ingredients that don't fit in any other.
+const Other = "Other"
+
+// The sections of a typical grocery store, in the order they are listed.
+var sectionOrder = []string{
+	"Produce", "Meat & Seafood", "Dairy & Eggs", "Bakery", "Grains & Pasta", "Canned & Dry Goods",
+	"Baking", "Spices", "Oils & Condiments", "Frozen", Other,
+}
+
+// An ingredient goes in the section of the first rule with a keyword whose words all appear in its
+// normalized name, so the order of the rules matters: "garlic powder" is a spice rather than produce,
+// and "chicken broth" is a canned good rather than meat.
+var sectionRules = []struct {
+	section  string
+	keywords []string
+}{
+	{"Frozen", []string{"frozen"}},
+	// before "pepper" below
+	{"Produce", []string{"bell pepper", "jalapeno", "jalapeño", "poblano", "chile pepper"}},
+	{"Spices", []string{
+		"dried", "powder", "pepper", "flake", "cayenne", "cumin",
+		"paprika", "cinnamon", "nutmeg", "oregano", "bay leaf", "salt", "seasoning", "allspice",
+		"curry", "turmeric", "peppercorn",
+	}},
+	{"Canned & Dry Goods", []string{
+		"broth", "stock", "bouillon", "bean", "lentil", "chickpea", "canned", "tomato paste",
+		"tomato sauce", "coconut milk", "salsa",
+	}},
+	{"Baking", []string{
+		"flour", "sugar", "baking soda", "baking powder", "yeast", "vanilla", "cocoa", "chocolate",
+		"cornstarch", "syrup",
+	}},
+	{"Oils & Condiments", []string{
+		"oil", "vinegar", "soy sauce", "sriracha", "hot sauce", "mustard", "ketchup", "mayonnaise",
+		"honey", "peanut butter", "sesame", "worcestershire", "cooking spray",
+	}},
+	{"Grains & Pasta", []string{
+		"rice", "pasta", "noodle", "spaghetti", "macaroni", "penne", "oat", "quinoa", "couscous",
+		"breadcrumb", "panko",
+	}},
+	{"Bakery", []string{"bread", "tortilla", "bun", "pita", "roll", "bagel", "naan"}},
+	{"Meat & Seafood", []string{
+		"chicken", "beef", "pork", "turkey", "sausage", "bacon", "ham", "shrimp", "salmon", "fish",
+		"tuna", "steak", "chorizo",
+	}},
+	{"Dairy & Eggs", []string{
+		"milk", "butter", "cheese", "cream", "yogurt", "egg", "parmesan", "mozzarella", "cheddar",
+		"feta", "half-and-half",
+	}},
+	{"Produce", []string{
+		"potato", "onion", "garlic", "shallot", "scallion", "carrot", "celery",
+		"tomato", "lettuce", "spinach", "kale", "cabbage", "broccoli",
+		"cauliflower", "zucchini", "squash", "mushroom", "cucumber", "corn", "pea", "avocado",
+		"lemon", "lime", "orange", "apple", "banana", "berry", "ginger", "cilantro", "parsley",
+		"basil", "thyme", "rosemary", "mint", "dill", "chive", "herb",
+	}},
+}
+
+// SectionOf returns the store section for an ingredient, given its normalized name (see
+// models.NormalizeName).
+func SectionOf(name string) string {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(name) {
+		words[word] = true
+	}
+	for _, rule := range sectionRules {
+		for _, keyword := range rule.keywords {
+			found := true
+			for _, word := range strings.Fields(keyword) {
+				if !words[word] {
+					found = false
+					break
+				}
+			}
+			if found {
+				return rule.section
+			}
+		}
+	}
+	return Other
+}
@@ -0,0 +1,25 @@
+package sites
+
+import (
+	"github.com/ejacobg/recipe-parser/recipe"
+	"golang.org/x/net/html"
+)
+
+// JSONLD is the fallback for sites without a dedicated adapter. It reads the schema.org Recipe
+// embedded in the page, which most recipe sites include for search engines.
+type JSONLD struct{}
+
+// Canonicalize can't guess a site's URL layout, so names are returned unchanged.
+// Full URLs should be used for these sites.
+func (JSONLD) Canonicalize(name string) string {
+	return name
+}
+
+func (JSONLD) Detect(doc *html.Node) bool {
+	_, err := recipe.FromJSONLD(doc)
+	return err == nil
+}
+
+func (JSONLD) Extract(doc *html.Node, opts ...recipe.Option) (*recipe.Result, error) {
+	return recipe.FromHTML(doc, append(opts, recipe.Strategies(recipe.JSONLD))...)
+}
@@ -0,0 +1,146 @@
+// Package sites maps recipe websites to the adapters that know how to parse them.
+package sites
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ejacobg/recipe-parser/fetch"
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"golang.org/x/net/html"
+)
+
+// SiteAdapter knows how to locate and parse the recipes on a particular website.
+type SiteAdapter interface {
+	// Canonicalize turns a recipe name (eg. "slow-cooker-mashed-potatoes") into the URL of its page.
+	// The canonicalized URL should match the Recipe.URL field once parsed.
+	Canonicalize(name string) string
+	// Detect reports whether the page contains a recipe that the adapter can extract.
+	Detect(doc *html.Node) bool
+	// Extract builds a recipe from the page. The options are passed on to recipe.FromHTML.
+	Extract(doc *html.Node, opts ...recipe.Option) (*recipe.Result, error)
+}
+
+// DefaultHost is the site that bare recipe names (rather than full URLs) are looked up on.
+const DefaultHost = "budgetbytes.com"
+
+var (
+	mu       sync.RWMutex
+	adapters = make(map[string]SiteAdapter)
+)
+
+// Register makes the adapter responsible for the given hosts. A host of "www.example.com" also
+// covers "example.com" and vice versa. Registering a host twice replaces the old adapter.
+func Register(a SiteAdapter, hosts ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, host := range hosts {
+		adapters[normalizeHost(host)] = a
+	}
+}
+
+// Lookup returns the adapter registered for the host, if any.
+func Lookup(host string) (SiteAdapter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := adapters[normalizeHost(host)]
+	return a, ok
+}
+
+// ForHost returns the adapter registered for the host. Unregistered hosts get a generic adapter
+// that only reads JSON-LD.
+func ForHost(host string) SiteAdapter {
+	if a, ok := Lookup(host); ok {
+		return a
+	}
+	return JSONLD{}
+}
+
+// Hosts returns every registered host.
+func Hosts() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	hosts := make([]string, 0, len(adapters))
+	for host := range adapters {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// normalizeHost drops the port and "www." from a host, eg. "www.budgetbytes.com:443" is
+// "budgetbytes.com". IPv6 literals lose their brackets, eg. "[::1]:8080" is "::1".
+func normalizeHost(host string) string {
+	host = strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.TrimPrefix(host, "www.")
+}
+
+// Canonicalize accepts either a recipe name or a full URL, and returns the URL of the recipe's
+// page. Names are resolved against DefaultHost. URLs for registered hosts are canonicalized by
+// their adapter, while other URLs are returned as given.
+func Canonicalize(nameOrURL string) string {
+	nameOrURL = strings.TrimSpace(nameOrURL)
+	u, err := url.Parse(nameOrURL)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return ForHost(DefaultHost).Canonicalize(nameOrURL)
+	}
+	if a, ok := Lookup(u.Host); ok {
+		return a.Canonicalize(strings.Trim(u.EscapedPath(), "/"))
+	}
+	return u.String()
+}
+
+// Parse picks the adapter for the URL's host and uses it to extract the recipe from the page.
+func Parse(source string, doc *html.Node, opts ...recipe.Option) (*recipe.Result, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	a := ForHost(u.Host)
+	if !a.Detect(doc) {
+		return nil, errors.New("no recipe found at " + source)
+	}
+	return a.Extract(doc, opts...)
+}
+
+// Fetch downloads the page at source with fetch.Default and parses it with the matching adapter.
+// "source" should be a canonicalized URL.
+func Fetch(source string, opts ...recipe.Option) (*recipe.Result, error) {
+	return FetchWith(context.Background(), fetch.Default, source, opts...)
+}
+
+// FetchWith is Fetch with a context and a fetcher of your own, eg. one with a cache.
+func FetchWith(ctx context.Context, f *fetch.Fetcher, source string, opts ...recipe.Option) (*recipe.Result, error) {
+	if _, err := url.Parse(source); err != nil {
+		return nil, err
+	}
+	page, err := f.Get(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePage(page, opts...)
+}
+
+// ParsePage parses a downloaded page with the matching adapter. A snapshot of the page is kept in
+// the result, so that it can be saved along with the recipe.
+func ParsePage(page *fetch.Response, opts ...recipe.Option) (*recipe.Result, error) {
+	doc, err := html.Parse(bytes.NewReader(page.Body))
+	if err != nil {
+		return nil, err
+	}
+	res, err := Parse(page.URL, doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res.Snapshot = models.NewSnapshot(page.URL, page.FetchedAt, page.StatusCode, page.Header, page.Body)
+	return res, nil
+}
@@ -0,0 +1,48 @@
+package sites
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"slow-cooker-mashed-potatoes", "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/"},
+		{" Slow-Cooker-Mashed-Potatoes/ ", "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/"},
+		{"slow%2Dcooker%2Dmashed%2Dpotatoes", "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/"},
+		{"https://budgetbytes.com/Slow-Cooker-Mashed-Potatoes", "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/"},
+		{"https://www.example.com/recipes/soup.html", "https://www.example.com/recipes/soup.html"},
+	}
+
+	for _, test := range tests {
+		if got := Canonicalize(test.in); got != test.want {
+			t.Errorf("Canonicalize(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestForHost(t *testing.T) {
+	if _, ok := ForHost("WWW.BudgetBytes.com:443").(WPRM); !ok {
+		t.Error("budgetbytes.com should use the WPRM adapter")
+	}
+	if _, ok := ForHost("www.example.com").(JSONLD); !ok {
+		t.Error("unregistered hosts should fall back to the JSON-LD adapter")
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"WWW.BudgetBytes.com", "budgetbytes.com"},
+		{"www.budgetbytes.com:443", "budgetbytes.com"},
+		{"[::1]:8080", "::1"},
+		{"[::1]", "::1"},
+		{"127.0.0.1:8080", "127.0.0.1"},
+	}
+
+	for _, test := range tests {
+		if got := normalizeHost(test.in); got != test.want {
+			t.Errorf("normalizeHost(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
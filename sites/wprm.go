@@ -0,0 +1,42 @@
+package sites
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/parser"
+	"github.com/ejacobg/recipe-parser/recipe"
+	"golang.org/x/net/html"
+)
+
+func init() {
+	Register(WPRM{BaseURL: "https://www.budgetbytes.com/"}, "www.budgetbytes.com")
+}
+
+// WPRM handles sites built on the WP Recipe Maker plugin, where each recipe lives at
+// BaseURL + name + "/".
+type WPRM struct {
+	BaseURL string
+}
+
+func (w WPRM) Canonicalize(name string) string {
+	// Convert from "%2D" to "-"
+	esc, err := url.PathUnescape(name)
+	if err != nil {
+		// Ignore any failures
+		esc = name
+	}
+	esc = strings.TrimSpace(esc)
+	esc = strings.Trim(esc, "/")
+	return w.BaseURL + strings.ToLower(esc) + "/"
+}
+
+// Detect accepts pages with either a recipe card or a JSON-LD recipe, since the recipe can still be
+// built from the JSON-LD if the card's markup changes.
+func (WPRM) Detect(doc *html.Node) bool {
+	return parser.FindRecipeCard(doc) != nil || JSONLD{}.Detect(doc)
+}
+
+func (WPRM) Extract(doc *html.Node, opts ...recipe.Option) (*recipe.Result, error) {
+	return recipe.FromHTML(doc, opts...)
+}
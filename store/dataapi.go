@@ -0,0 +1,309 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// DefaultDataAPIURL is the endpoint of the Atlas Data API app that the /api/data route uses.
+const DefaultDataAPIURL = "https://data.mongodb-api.com/app/data-gicsu/endpoint/data/v1/action/"
+
+// DataAPI stores recipes in a MongoDB collection through the Atlas Data API, which only needs HTTP
+// rather than a driver connection.
+// Requests and responses are sent as Extended JSON (EJSON), so that recipes are stored with the same
+// BSON types that the driver would use (eg. recipe times as numbers rather than strings).
+type DataAPI struct {
+	BaseURL    string // defaults to DefaultDataAPIURL
+	Key        string
+	DataSource string
+	Database   string
+	Collection string
+	Client     *http.Client // defaults to http.DefaultClient
+}
+
+// Required by all request types.
+type required struct {
+	DataSource string `bson:"dataSource"`
+	Database   string `bson:"database"`
+	Collection string `bson:"collection"`
+}
+
+// The bson package skips unexported fields, so "required" can't be embedded like it could with
+// encoding/json.
+
+type findOne struct {
+	Required   required `bson:",inline"`
+	Filter     bson.D   `bson:"filter"`
+	Projection bson.D   `bson:"projection,omitempty"`
+}
+
+func (*findOne) action() string {
+	return "findOne"
+}
+
+type find struct {
+	Required   required `bson:",inline"`
+	Filter     bson.D   `bson:"filter"`
+	Projection bson.D   `bson:"projection,omitempty"`
+	Sort       bson.D   `bson:"sort,omitempty"`
+}
+
+func (*find) action() string {
+	return "find"
+}
+
+type updateOne struct {
+	Required required `bson:",inline"`
+	Filter   bson.D   `bson:"filter"`
+	Update   bson.D   `bson:"update"`
+	Upsert   bool     `bson:"upsert,omitempty"`
+}
+
+func (*updateOne) action() string {
+	return "updateOne"
+}
+
+type replaceOne struct {
+	Required    required    `bson:",inline"`
+	Filter      bson.D      `bson:"filter"`
+	Replacement interface{} `bson:"replacement"` // a recipe or a plan
+	Upsert      bool        `bson:"upsert,omitempty"`
+}
+
+func (*replaceOne) action() string {
+	return "replaceOne"
+}
+
+type deleteOne struct {
+	Required required `bson:",inline"`
+	Filter   bson.D   `bson:"filter"`
+}
+
+func (*deleteOne) action() string {
+	return "deleteOne"
+}
+
+type aggregate struct {
+	Required required `bson:",inline"`
+	Pipeline bson.A   `bson:"pipeline"`
+}
+
+func (*aggregate) action() string {
+	return "aggregate"
+}
+
+type actioner interface {
+	action() string
+}
+
+// Every response type the store needs, read from the same struct. Missing fields are left as zero.
+type response struct {
+	Document  bson.RawValue `bson:"document"`  // see document
+	Documents bson.RawValue `bson:"documents"` // see documents
+
+	MatchedCount  int64 `bson:"matchedCount"`
+	DeletedCount  int64 `bson:"deletedCount"`
+	ModifiedCount int64 `bson:"modifiedCount"`
+}
+
+var noID = bson.D{{Key: "_id", Value: 0}}
+
+// document decodes the document returned by findOne, a recipe or a plan. It reports whether there
+// was one.
+func (r *response) document(v interface{}) (bool, error) {
+	if r.Document.Type == 0 || r.Document.Type == bsontype.Null {
+		return false, nil
+	}
+	return true, r.Document.Unmarshal(v)
+}
+
+// documents decodes the documents returned by find or aggregate, whose type depends on the action.
+func (r *response) documents(v interface{}) error {
+	if r.Documents.Type == 0 {
+		return nil
+	}
+	return r.Documents.Unmarshal(v)
+}
+
+func (d *DataAPI) required() required {
+	return required{d.DataSource, d.Database, d.Collection}
+}
+
+// plans is the same as required, but for the collection of meal plans.
+func (d *DataAPI) plans() required {
+	return required{d.DataSource, d.Database, PlansCollection}
+}
+
+// send performs the action and decodes the response.
+func (d *DataAPI) send(ctx context.Context, a actioner) (*response, error) {
+	body, err := bson.MarshalExtJSON(a, false, false)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := d.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultDataAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+a.action(), bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = http.Header{
+		"Api-Key":      {d.Key},
+		"Accept":       {"application/ejson"},
+		"Content-Type": {"application/ejson"},
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err = io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.New("data API " + a.action() + ": " + res.Status + ": " + string(body))
+	}
+	r := &response{}
+	return r, bson.UnmarshalExtJSON(body, false, r)
+}
+
+func (d *DataAPI) get(ctx context.Context, filter bson.D) (*models.Recipe, error) {
+	res, err := d.send(ctx, &findOne{d.required(), filter, noID})
+	if err != nil {
+		return nil, err
+	}
+	rcp := &models.Recipe{}
+	found, err := res.document(rcp)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return rcp, nil
+}
+
+func (d *DataAPI) GetByURL(ctx context.Context, url string) (*models.Recipe, error) {
+	return d.get(ctx, bson.D{{Key: "url", Value: url}})
+}
+
+func (d *DataAPI) GetByID(ctx context.Context, id string) (*models.Recipe, error) {
+	return d.get(ctx, bson.D{{Key: "id", Value: id}})
+}
+
+// Insert works the same way as Mongo.Insert.
+func (d *DataAPI) Insert(ctx context.Context, rcp *models.Recipe) error {
+	update := bson.D{{Key: "$setOnInsert", Value: rcp}}
+	res, err := d.send(ctx, &updateOne{d.required(), sameRecipe(rcp), update, true})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount > 0 {
+		return ErrExists
+	}
+	return nil
+}
+
+func (d *DataAPI) Replace(ctx context.Context, rcp *models.Recipe) error {
+	res, err := d.send(ctx, &replaceOne{d.required(), bson.D{{Key: "id", Value: rcp.ID}}, rcp, false})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (d *DataAPI) Delete(ctx context.Context, id string) error {
+	res, err := d.send(ctx, &deleteOne{d.required(), bson.D{{Key: "id", Value: id}}})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (d *DataAPI) List(ctx context.Context) ([]*models.Recipe, error) {
+	res, err := d.send(ctx, &find{d.required(), bson.D{}, noID, nil})
+	if err != nil {
+		return nil, err
+	}
+	var recipes []*models.Recipe
+	return recipes, res.documents(&recipes)
+}
+
+func (d *DataAPI) Search(ctx context.Context, q Query) (*SearchResult, error) {
+	res, err := d.send(ctx, &aggregate{d.required(), q.pipeline()})
+	if err != nil {
+		return nil, err
+	}
+	var results []facetResult
+	if err = res.documents(&results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &SearchResult{Recipes: []*models.Recipe{}}, nil
+	}
+	return results[0].result(), nil
+}
+
+func (d *DataAPI) GetPlan(ctx context.Context, id string) (*models.Plan, error) {
+	res, err := d.send(ctx, &findOne{d.plans(), bson.D{{Key: "id", Value: id}}, noID})
+	if err != nil {
+		return nil, err
+	}
+	plan := &models.Plan{}
+	found, err := res.document(plan)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrPlanNotFound
+	}
+	return plan, nil
+}
+
+func (d *DataAPI) SavePlan(ctx context.Context, plan *models.Plan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	_, err := d.send(ctx, &replaceOne{d.plans(), bson.D{{Key: "id", Value: plan.ID}}, plan, true})
+	return err
+}
+
+func (d *DataAPI) DeletePlan(ctx context.Context, id string) error {
+	res, err := d.send(ctx, &deleteOne{d.plans(), bson.D{{Key: "id", Value: id}}})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrPlanNotFound
+	}
+	return nil
+}
+
+func (d *DataAPI) ListPlans(ctx context.Context) ([]*models.Plan, error) {
+	res, err := d.send(ctx, &find{d.plans(), bson.D{}, noID, bson.D{{Key: "id", Value: 1}}})
+	if err != nil {
+		return nil, err
+	}
+	plans := []*models.Plan{}
+	return plans, res.documents(&plans)
+}
@@ -0,0 +1,523 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+)
+
+// IndexFile is the name of the index that Dir keeps in its directory.
+const IndexFile = ".index.json"
+
+// Dir stores recipes as JSON files in a local directory, eg. the ./database directory that the CLI
+// saves to. Each recipe is saved as <name>.json, where the name is made from its URL's host and
+// path. Recipes saved before the host was added are named after the last part of the URL.
+//
+// Lookups go through an index of every recipe's ID, URL and name, which is saved next to the
+// recipes so it doesn't have to be rebuilt every time. Files that are added, changed or removed by
+// hand are picked up on the next call. Files are written atomically, so a crash (or a reader in
+// another process) never sees a half-written recipe.
+type Dir struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*indexEntry // keyed by file name
+	byID    map[string]string
+	byURL   map[string]string
+	byName  map[string]string // lowercased recipe name, the file name without ".json", and the URL's last part
+}
+
+type indexEntry struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Name    string    `json:"name"`
+	ModTime time.Time `json:"modTime"`
+	Size    int64     `json:"size"`
+}
+
+// NewDir returns a store over the given directory, creating it if needed.
+func NewDir(dir string) (*Dir, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &Dir{path: dir, entries: make(map[string]*indexEntry)}
+	if data, err := os.ReadFile(filepath.Join(dir, IndexFile)); err == nil {
+		// a broken index is rebuilt from scratch
+		if json.Unmarshal(data, &d.entries) != nil || d.entries == nil {
+			d.entries = make(map[string]*indexEntry)
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Path returns the directory that the recipes are stored in.
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// FileName returns the name of the file that the recipe is saved to, eg.
+// "budgetbytes-com-slow-cooker-mashed-potatoes.json". Recipes without a URL are named after their ID. Only letters,
+// digits, "-" and "_" are kept, since the ID may come from the page and could be eg. "../../x".
+func FileName(rcp *models.Recipe) string {
+	name := nameFromURL(rcp.URL)
+	if name == "" {
+		name = slug(rcp.ID)
+	}
+	return name + ".json"
+}
+
+// nameFromURL names a file after the URL's host (without "www.") and path, so that the same
+// recipe name on two websites doesn't share a file.
+func nameFromURL(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return lastPart(source)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return slug(host + "/" + u.Path)
+}
+
+// lastPart returns the last part of the URL's path, eg. "slow-cooker-mashed-potatoes", which is
+// what files were named after before nameFromURL added the host.
+func lastPart(source string) string {
+	return slug(path.Base(strings.TrimSuffix(source, "/")))
+}
+
+// slug replaces each run of characters other than letters, digits, "-" and "_" with a "-".
+func slug(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	})
+	return strings.Trim(strings.Join(words, "-"), "-")
+}
+
+// refresh brings the index up to date with the files in the directory, re-reading any file whose
+// size or modification time doesn't match the index. The index is saved if anything changed.
+// d.mu must be held.
+func (d *Dir) refresh() error {
+	dirEntries, err := os.ReadDir(d.path)
+	if err != nil {
+		return err
+	}
+	changed := false
+	seen := make(map[string]bool)
+	for _, dirEntry := range dirEntries {
+		file := dirEntry.Name()
+		if dirEntry.IsDir() || strings.HasPrefix(file, ".") || !strings.HasSuffix(file, ".json") {
+			continue
+		}
+		seen[file] = true
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		if e, ok := d.entries[file]; ok && e.ModTime.Equal(info.ModTime()) && e.Size == info.Size() {
+			continue
+		}
+		rcp, err := d.read(file)
+		if err != nil {
+			return err
+		}
+		d.entries[file] = newEntry(rcp, info)
+		changed = true
+	}
+	for file := range d.entries {
+		if !seen[file] {
+			delete(d.entries, file)
+			changed = true
+		}
+	}
+
+	d.byID = make(map[string]string)
+	d.byURL = make(map[string]string)
+	d.byName = make(map[string]string)
+	for file, e := range d.entries {
+		d.byID[e.ID] = file
+		if e.URL != "" {
+			d.byURL[e.URL] = file
+		}
+		d.byName[strings.ToLower(e.Name)] = file
+		d.byName[strings.TrimSuffix(file, ".json")] = file
+		// a file that is actually named this way comes first
+		if _, ok := d.byName[lastPart(e.URL)]; !ok && e.URL != "" {
+			d.byName[lastPart(e.URL)] = file
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(d.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(d.path, IndexFile), data)
+}
+
+func newEntry(rcp *models.Recipe, info os.FileInfo) *indexEntry {
+	return &indexEntry{
+		ID:      rcp.ID,
+		URL:     rcp.URL,
+		Name:    rcp.Name,
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+	}
+}
+
+func (d *Dir) read(file string) (*models.Recipe, error) {
+	data, err := os.ReadFile(filepath.Join(d.path, file))
+	if err != nil {
+		return nil, err
+	}
+	rcp := &models.Recipe{}
+	if err = json.Unmarshal(data, rcp); err != nil {
+		return nil, errors.New(file + ": " + err.Error())
+	}
+	return rcp, nil
+}
+
+// write saves the recipe and updates the index. d.mu must be held.
+func (d *Dir) write(file string, rcp *models.Recipe) error {
+	name := filepath.Join(d.path, file)
+	// FileName should never give a path outside the directory, but make sure
+	if rel, err := filepath.Rel(d.path, name); err != nil || rel != filepath.Base(rel) || strings.HasPrefix(rel, "..") {
+		return errors.New("invalid file name " + file)
+	}
+	data, err := json.MarshalIndent(rcp, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = writeFile(name, data); err != nil {
+		return err
+	}
+	return d.refresh()
+}
+
+// writeFile writes the data to a temporary file and renames it over the target, so that the
+// target is either the old or the new file and never a partial one.
+func writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// get refreshes the index, then reads the file that the lookup function returns.
+func (d *Dir) get(lookup func() (string, bool)) (*models.Recipe, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return nil, err
+	}
+	file, ok := lookup()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return d.read(file)
+}
+
+// GetByURL also accepts a file named after the URL whose recipe has no URL, since older recipes
+// were saved without one.
+func (d *Dir) GetByURL(_ context.Context, url string) (*models.Recipe, error) {
+	return d.get(func() (string, bool) {
+		if file, ok := d.byURL[url]; ok {
+			return file, true
+		}
+		file := lastPart(url) + ".json"
+		if e, ok := d.entries[file]; ok && e.URL == "" {
+			return file, true
+		}
+		return "", false
+	})
+}
+
+func (d *Dir) GetByID(_ context.Context, id string) (*models.Recipe, error) {
+	return d.get(func() (string, bool) {
+		file, ok := d.byID[id]
+		return file, ok
+	})
+}
+
+// GetByName returns the recipe with the given name, either as written on the recipe (ignoring
+// case, eg. "slow cooker mashed potatoes") or as used in its URL (eg. "slow-cooker-mashed-potatoes").
+func (d *Dir) GetByName(_ context.Context, name string) (*models.Recipe, error) {
+	return d.get(func() (string, bool) {
+		file, ok := d.byName[strings.ToLower(strings.TrimSpace(name))]
+		return file, ok
+	})
+}
+
+func (d *Dir) Insert(_ context.Context, rcp *models.Recipe) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return err
+	}
+	if _, ok := d.byID[rcp.ID]; ok {
+		return ErrExists
+	}
+	if _, ok := d.byURL[rcp.URL]; ok && rcp.URL != "" {
+		return ErrExists
+	}
+	file := FileName(rcp)
+	if file == ".json" {
+		return errors.New("recipe " + rcp.ID + " has no URL or ID to name its file after")
+	}
+	if _, ok := d.entries[file]; ok {
+		return ErrExists
+	}
+	return d.write(file, rcp)
+}
+
+func (d *Dir) Replace(_ context.Context, rcp *models.Recipe) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return err
+	}
+	file, ok := d.byID[rcp.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	return d.write(file, rcp)
+}
+
+// ReplaceID rewrites the old recipe's file, so its snapshot stays with it.
+func (d *Dir) ReplaceID(_ context.Context, oldID string, rcp *models.Recipe) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return err
+	}
+	file, ok := d.byID[oldID]
+	if !ok {
+		return ErrNotFound
+	}
+	if other, ok := d.byID[rcp.ID]; ok && other != file {
+		return ErrExists
+	}
+	if other, ok := d.byURL[rcp.URL]; ok && rcp.URL != "" && other != file {
+		return ErrExists
+	}
+	return d.write(file, rcp)
+}
+
+func (d *Dir) Delete(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return err
+	}
+	file, ok := d.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := os.Remove(filepath.Join(d.path, file)); err != nil {
+		return err
+	}
+	if err := os.Remove(d.snapshotFile(file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return d.refresh()
+}
+
+// List returns every recipe, sorted by name.
+func (d *Dir) List(_ context.Context) ([]*models.Recipe, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(d.entries))
+	for file := range d.entries {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return d.entries[files[i]].Name < d.entries[files[j]].Name
+	})
+
+	recipes := make([]*models.Recipe, 0, len(files))
+	for _, file := range files {
+		rcp, err := d.read(file)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, rcp)
+	}
+	return recipes, nil
+}
+
+// Search filters every recipe in memory, which is fine for the few hundred recipes a directory is
+// likely to hold.
+func (d *Dir) Search(ctx context.Context, q Query) (*SearchResult, error) {
+	recipes, err := d.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return searchAll(recipes, q), nil
+}
+
+// PlansDir is the subdirectory that Dir saves meal plans in, as <id>.json.
+const PlansDir = "plans"
+
+func (d *Dir) planFile(id string) string {
+	return filepath.Join(d.path, PlansDir, id+".json")
+}
+
+func (d *Dir) GetPlan(_ context.Context, id string) (*models.Plan, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !validPlanID(id) {
+		return nil, ErrPlanNotFound
+	}
+	return readPlan(d.planFile(id))
+}
+
+func readPlan(file string) (*models.Plan, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	plan := &models.Plan{}
+	if err = json.Unmarshal(data, plan); err != nil {
+		return nil, errors.New(filepath.Base(file) + ": " + err.Error())
+	}
+	return plan, nil
+}
+
+func (d *Dir) SavePlan(_ context.Context, plan *models.Plan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err = os.MkdirAll(filepath.Join(d.path, PlansDir), 0o755); err != nil {
+		return err
+	}
+	return writeFile(d.planFile(plan.ID), data)
+}
+
+func (d *Dir) DeletePlan(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !validPlanID(id) {
+		return ErrPlanNotFound
+	}
+	err := os.Remove(d.planFile(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrPlanNotFound
+	}
+	return err
+}
+
+func (d *Dir) ListPlans(_ context.Context) ([]*models.Plan, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	files, err := filepath.Glob(filepath.Join(d.path, PlansDir, "[^.]*.json"))
+	if err != nil {
+		return nil, err
+	}
+	// Glob sorts the files, and so the plans, by ID
+	plans := make([]*models.Plan, 0, len(files))
+	for _, file := range files {
+		plan, err := readPlan(file)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// validPlanID reports whether the ID could belong to a saved plan. Checking it first keeps IDs like
+// "../x" from reaching the file system.
+func validPlanID(id string) bool {
+	return (&models.Plan{ID: id}).Validate() == nil
+}
+
+// SnapshotsDir is the subdirectory that Dir keeps the snapshots of recipe pages in. Each snapshot
+// is named after its recipe, eg. snapshots/slow-cooker-mashed-potatoes.html.gz for
+// slow-cooker-mashed-potatoes.json.
+const SnapshotsDir = "snapshots"
+
+func (d *Dir) snapshotFile(file string) string {
+	return filepath.Join(d.path, SnapshotsDir, strings.TrimSuffix(file, ".json")+".html.gz")
+}
+
+func (d *Dir) SaveSnapshot(_ context.Context, rcp *models.Recipe, snap *models.Snapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return err
+	}
+	file, ok := d.byID[rcp.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	data, err := encodeSnapshot(snap)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Join(d.path, SnapshotsDir), 0o755); err != nil {
+		return err
+	}
+	return writeFile(d.snapshotFile(file), data)
+}
+
+func (d *Dir) GetSnapshot(_ context.Context, rcp *models.Recipe) (*models.Snapshot, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.refresh(); err != nil {
+		return nil, err
+	}
+	file, ok := d.byID[rcp.ID]
+	if !ok {
+		return nil, ErrNoSnapshot
+	}
+	data, err := os.ReadFile(d.snapshotFile(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshot(data)
+}
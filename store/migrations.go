@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations builds the SQLite schema, one step at a time. The database remembers how many steps
+// have been applied (in PRAGMA user_version), so opening an older database only runs the new ones.
+// Never edit a migration that has been released; add a new one instead.
+var migrations = []string{
+	// 1: recipes, ingredients and instructions
+	`CREATE TABLE recipes (
+		pk            INTEGER PRIMARY KEY,
+		id            TEXT NOT NULL UNIQUE,
+		url           TEXT NOT NULL DEFAULT '',
+		name          TEXT NOT NULL DEFAULT '',
+		image         TEXT NOT NULL DEFAULT '',
+		summary       TEXT NOT NULL DEFAULT '',
+		author        TEXT NOT NULL DEFAULT '',
+		prep_time     INTEGER NOT NULL DEFAULT 0, -- minutes
+		cook_time     INTEGER NOT NULL DEFAULT 0,
+		total_time    INTEGER NOT NULL DEFAULT 0,
+		servings      REAL NOT NULL DEFAULT 0,
+		servings_unit TEXT NOT NULL DEFAULT '',
+		course        TEXT, -- JSON lists and objects
+		cuisine       TEXT,
+		keywords      TEXT,
+		nutrition     TEXT,
+		cost          TEXT
+	);
+	CREATE UNIQUE INDEX recipes_url ON recipes (url) WHERE url != '';
+
+	CREATE TABLE ingredients (
+		recipe         INTEGER NOT NULL REFERENCES recipes (pk) ON DELETE CASCADE,
+		group_position INTEGER NOT NULL,
+		group_name     TEXT NOT NULL DEFAULT '',
+		position       INTEGER NOT NULL,
+		amount         TEXT NOT NULL DEFAULT '',
+		unit           TEXT NOT NULL DEFAULT '',
+		name           TEXT NOT NULL DEFAULT '',
+		notes          TEXT NOT NULL DEFAULT '',
+		cost_cents     INTEGER,
+		cost_currency  TEXT,
+		quantity_value REAL,
+		quantity_max   REAL,
+		to_taste       INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (recipe, group_position, position)
+	);
+	CREATE INDEX ingredients_name ON ingredients (name);
+
+	CREATE TABLE instructions (
+		recipe   INTEGER NOT NULL REFERENCES recipes (pk) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		text     TEXT NOT NULL,
+		PRIMARY KEY (recipe, position)
+	);`,
+
+	// 2: full-text index over names, ingredient names and instructions, keyed by recipes.pk
+	`CREATE VIRTUAL TABLE recipes_fts USING fts5 (name, ingredients, instructions);
+	INSERT INTO recipes_fts (rowid, name, ingredients, instructions)
+	SELECT pk, name,
+		(SELECT group_concat(name, ' ') FROM ingredients WHERE recipe = pk),
+		(SELECT group_concat(text, ' ') FROM instructions WHERE recipe = pk)
+	FROM recipes;`,
+
+	// 3: meal plans, which refer to recipes by ID so that a plan can outlive a deleted recipe
+	`CREATE TABLE plans (
+		pk   INTEGER PRIMARY KEY,
+		id   TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE plan_meals (
+		plan      INTEGER NOT NULL REFERENCES plans (pk) ON DELETE CASCADE,
+		date      TEXT NOT NULL, -- YYYY-MM-DD
+		slot      TEXT NOT NULL,
+		recipe_id TEXT NOT NULL,
+		servings  REAL NOT NULL DEFAULT 0,
+		PRIMARY KEY (plan, date, slot)
+	);`,
+
+	// 4: the page each recipe was parsed from, with the body gzipped
+	`CREATE TABLE snapshots (
+		recipe     INTEGER PRIMARY KEY REFERENCES recipes (pk) ON DELETE CASCADE,
+		url        TEXT NOT NULL,
+		fetched_at TEXT NOT NULL, -- RFC 3339
+		status     INTEGER NOT NULL,
+		header     TEXT, -- JSON object
+		sha256     TEXT NOT NULL,
+		body       BLOB NOT NULL
+	);`,
+}
+
+// migrate applies any migrations that haven't been run on the database yet. Each migration runs in
+// its own transaction.
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this program (%d)", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA doesn't accept parameters
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mongo stores recipes in a MongoDB collection using the Go driver.
+type Mongo struct {
+	coll *mongo.Collection
+}
+
+// NewMongo returns a store backed by the given collection. The caller is responsible for connecting
+// and disconnecting the client.
+func NewMongo(coll *mongo.Collection) *Mongo {
+	return &Mongo{coll}
+}
+
+func (m *Mongo) get(ctx context.Context, filter bson.D) (*models.Recipe, error) {
+	rcp := &models.Recipe{}
+	err := m.coll.FindOne(ctx, filter).Decode(rcp)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rcp, nil
+}
+
+func (m *Mongo) GetByURL(ctx context.Context, url string) (*models.Recipe, error) {
+	return m.get(ctx, bson.D{{Key: "url", Value: url}})
+}
+
+func (m *Mongo) GetByID(ctx context.Context, id string) (*models.Recipe, error) {
+	return m.get(ctx, bson.D{{Key: "id", Value: id}})
+}
+
+// Insert only writes the recipe if nothing matches its ID or URL, so that two requests for the
+// same recipe can't both insert it.
+func (m *Mongo) Insert(ctx context.Context, rcp *models.Recipe) error {
+	opts := options.Update().SetUpsert(true)
+	result, err := m.coll.UpdateOne(ctx, sameRecipe(rcp), bson.D{{Key: "$setOnInsert", Value: rcp}}, opts)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return ErrExists
+	}
+	return nil
+}
+
+func (m *Mongo) Replace(ctx context.Context, rcp *models.Recipe) error {
+	result, err := m.coll.ReplaceOne(ctx, bson.D{{Key: "id", Value: rcp.ID}}, rcp)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *Mongo) Delete(ctx context.Context, id string) error {
+	result, err := m.coll.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *Mongo) List(ctx context.Context) ([]*models.Recipe, error) {
+	cursor, err := m.coll.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+	var recipes []*models.Recipe
+	err = cursor.All(ctx, &recipes)
+	return recipes, err
+}
+
+// sameRecipe matches any document with the recipe's ID or URL.
+func sameRecipe(rcp *models.Recipe) bson.D {
+	if rcp.URL == "" {
+		return bson.D{{Key: "id", Value: rcp.ID}}
+	}
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "id", Value: rcp.ID}},
+		bson.D{{Key: "url", Value: rcp.URL}},
+	}}}
+}
+
+func (m *Mongo) Search(ctx context.Context, q Query) (*SearchResult, error) {
+	cursor, err := m.coll.Aggregate(ctx, q.pipeline())
+	if err != nil {
+		return nil, err
+	}
+	var results []facetResult
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &SearchResult{Recipes: []*models.Recipe{}}, nil
+	}
+	return results[0].result(), nil
+}
+
+// plans returns the collection of meal plans, in the same database as the recipes.
+func (m *Mongo) plans() *mongo.Collection {
+	return m.coll.Database().Collection(PlansCollection)
+}
+
+func (m *Mongo) GetPlan(ctx context.Context, id string) (*models.Plan, error) {
+	plan := &models.Plan{}
+	err := m.plans().FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(plan)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (m *Mongo) SavePlan(ctx context.Context, plan *models.Plan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := m.plans().ReplaceOne(ctx, bson.D{{Key: "id", Value: plan.ID}}, plan, opts)
+	return err
+}
+
+func (m *Mongo) DeletePlan(ctx context.Context, id string) error {
+	result, err := m.plans().DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrPlanNotFound
+	}
+	return nil
+}
+
+func (m *Mongo) ListPlans(ctx context.Context) ([]*models.Plan, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	cursor, err := m.plans().Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	plans := []*models.Plan{}
+	err = cursor.All(ctx, &plans)
+	return plans, err
+}
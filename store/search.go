@@ -0,0 +1,288 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Query describes a recipe search. Empty fields don't filter anything.
+type Query struct {
+	// Text must appear in the recipe's name, ingredient names or instructions. Every word has to be
+	// found, but not necessarily in the same place.
+	Text string
+	// Include lists ingredients that the recipe must use, all of them. Exclude lists ingredients it
+	// must not use. An ingredient matches if its name contains the given text, so "garlic" matches
+	// "garlic, minced".
+	Include []string
+	Exclude []string
+	// MaxCostPerServing is the most a serving may cost, in cents (see Recipe.CostPerServing).
+	// Recipes without a cost are left out when it is set.
+	MaxCostPerServing int64
+	// MaxTime is the longest the recipe may take to make (see Recipe.TimeNeeded). Recipes without a
+	// time are left out when it is set.
+	MaxTime models.Duration
+
+	// Sort is the order of the results: SortName (the default), SortTime or SortCost.
+	Sort SortKey
+	// Descending reverses the order.
+	Descending bool
+	// Offset skips that many results, and Limit returns at most that many. A limit of zero returns
+	// every result.
+	Offset, Limit int
+}
+
+// SortKey names a field that search results can be sorted by.
+type SortKey string
+
+const (
+	SortName SortKey = "name"
+	SortTime SortKey = "time"
+	SortCost SortKey = "cost"
+)
+
+// ParseSortKey reads a sort key, eg. "cost". A leading "-" sorts in descending order, eg. "-time".
+func ParseSortKey(s string) (key SortKey, descending bool, err error) {
+	if strings.HasPrefix(s, "-") {
+		s, descending = s[1:], true
+	}
+	switch key = SortKey(s); key {
+	case "":
+		return SortName, descending, nil
+	case SortName, SortTime, SortCost:
+		return key, descending, nil
+	}
+	return "", false, errors.New("can't sort by " + s + `, use "name", "time" or "cost"`)
+}
+
+// SearchResult is a page of search results.
+type SearchResult struct {
+	// Total is the number of recipes matching the query, ignoring Offset and Limit.
+	Total   int              `json:"total"`
+	Recipes []*models.Recipe `json:"recipes"`
+}
+
+// Searcher is implemented by stores that can search their recipes. Every store in this package
+// does.
+type Searcher interface {
+	Search(ctx context.Context, q Query) (*SearchResult, error)
+}
+
+// words splits the free text into lowercased words.
+func (q *Query) words() []string {
+	return strings.Fields(strings.ToLower(q.Text))
+}
+
+// Matches reports whether the recipe passes every filter of the query. Stores that can't search
+// natively (eg. Dir) use this to filter their recipes.
+func (q *Query) Matches(rcp *models.Recipe) bool {
+	var names []string
+	for _, i := range rcp.AllIngredients() {
+		names = append(names, strings.ToLower(i.Name))
+	}
+	uses := func(ingredient string) bool {
+		ingredient = strings.ToLower(ingredient)
+		for _, name := range names {
+			if strings.Contains(name, ingredient) {
+				return true
+			}
+		}
+		return false
+	}
+
+	text := strings.ToLower(rcp.Name + "\n" + strings.Join(names, "\n") + "\n" + strings.Join(rcp.Instructions, "\n"))
+	for _, word := range q.words() {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	for _, ingredient := range q.Include {
+		if !uses(ingredient) {
+			return false
+		}
+	}
+	for _, ingredient := range q.Exclude {
+		if uses(ingredient) {
+			return false
+		}
+	}
+	if q.MaxCostPerServing > 0 {
+		cost, ok := rcp.CostPerServing()
+		if !ok || cost.Cents > q.MaxCostPerServing {
+			return false
+		}
+	}
+	if q.MaxTime > 0 {
+		if t := rcp.TimeNeeded(); t <= 0 || t > q.MaxTime {
+			return false
+		}
+	}
+	return true
+}
+
+// searchAll filters, sorts and pages a list of recipes in memory.
+func searchAll(recipes []*models.Recipe, q Query) *SearchResult {
+	var matched []*models.Recipe
+	for _, rcp := range recipes {
+		if q.Matches(rcp) {
+			matched = append(matched, rcp)
+		}
+	}
+
+	less := func(a, b *models.Recipe) bool { return a.Name < b.Name }
+	switch q.Sort {
+	case SortTime:
+		less = func(a, b *models.Recipe) bool { return a.TimeNeeded() < b.TimeNeeded() }
+	case SortCost:
+		less = func(a, b *models.Recipe) bool {
+			x, _ := a.CostPerServing()
+			y, _ := b.CostPerServing()
+			return x.Cents < y.Cents
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if q.Descending {
+			return less(matched[j], matched[i])
+		}
+		return less(matched[i], matched[j])
+	})
+
+	res := &SearchResult{Total: len(matched), Recipes: []*models.Recipe{}}
+	if q.Offset < len(matched) {
+		matched = matched[q.Offset:]
+		if q.Limit > 0 && q.Limit < len(matched) {
+			matched = matched[:q.Limit]
+		}
+		res.Recipes = matched
+	}
+	return res
+}
+
+// Both the ingredients' new (grouped) and old (flat) layouts may be stored in MongoDB, see
+// models.IngredientGroups.
+var ingredientNameFields = []string{"ingredients.ingredients.name", "ingredients.name"}
+
+func containsRegex(s string) primitive.Regex {
+	return primitive.Regex{Pattern: regexp.QuoteMeta(s), Options: "i"}
+}
+
+// pipeline returns a MongoDB aggregation pipeline that runs the query. It produces a single
+// document with the total number of matches and the requested page of recipes, which decodes into
+// a facetResult.
+func (q *Query) pipeline() bson.A {
+	var and bson.A
+	for _, word := range q.words() {
+		or := bson.A{bson.D{{Key: "name", Value: containsRegex(word)}}}
+		for _, field := range append(ingredientNameFields, "instructions") {
+			or = append(or, bson.D{{Key: field, Value: containsRegex(word)}})
+		}
+		and = append(and, bson.D{{Key: "$or", Value: or}})
+	}
+	for _, ingredient := range q.Include {
+		var or bson.A
+		for _, field := range ingredientNameFields {
+			or = append(or, bson.D{{Key: field, Value: containsRegex(ingredient)}})
+		}
+		and = append(and, bson.D{{Key: "$or", Value: or}})
+	}
+	for _, ingredient := range q.Exclude {
+		for _, field := range ingredientNameFields {
+			and = append(and, bson.D{{Key: field, Value: bson.D{{Key: "$not", Value: containsRegex(ingredient)}}}})
+		}
+	}
+	if q.MaxCostPerServing > 0 {
+		and = append(and, bson.D{{Key: "_costPerServing", Value: bson.D{{Key: "$lte", Value: q.MaxCostPerServing}}}})
+	}
+	if q.MaxTime > 0 {
+		and = append(and, bson.D{{Key: "_timeNeeded", Value: bson.D{
+			{Key: "$gt", Value: 0},
+			{Key: "$lte", Value: q.MaxTime.Minutes()},
+		}}})
+	}
+	match := bson.D{}
+	if len(and) > 0 {
+		match = bson.D{{Key: "$and", Value: and}}
+	}
+
+	sortField := "name"
+	switch q.Sort {
+	case SortTime:
+		sortField = "_timeNeeded"
+	case SortCost:
+		sortField = "_costPerServing"
+	}
+	direction := 1
+	if q.Descending {
+		direction = -1
+	}
+	order := bson.D{{Key: sortField, Value: direction}}
+	if sortField != "name" {
+		order = append(order, bson.E{Key: "name", Value: 1})
+	}
+	page := bson.A{bson.D{{Key: "$skip", Value: q.Offset}}}
+	if q.Limit > 0 {
+		page = append(page, bson.D{{Key: "$limit", Value: q.Limit}})
+	}
+	page = append(page, bson.D{{Key: "$project", Value: bson.D{
+		{Key: "_id", Value: 0}, {Key: "_costPerServing", Value: 0}, {Key: "_timeNeeded", Value: 0},
+	}}})
+
+	return bson.A{
+		// the same calculations as Recipe.CostPerServing and Recipe.TimeNeeded
+		bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: "_costPerServing", Value: bson.D{{Key: "$ifNull", Value: bson.A{
+				"$cost.serving.cents",
+				bson.D{{Key: "$cond", Value: bson.A{
+					bson.D{{Key: "$gt", Value: bson.A{"$servings", 0}}},
+					bson.D{{Key: "$round", Value: bson.A{
+						bson.D{{Key: "$divide", Value: bson.A{
+							bson.D{{Key: "$ifNull", Value: bson.A{"$cost.recipe.cents", "$cost.ingredients.cents"}}},
+							"$servings",
+						}}},
+						0,
+					}}},
+					nil,
+				}}},
+			}}}},
+			{Key: "_timeNeeded", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$gt", Value: bson.A{"$totalTime", 0}}},
+				"$totalTime",
+				bson.D{{Key: "$add", Value: bson.A{
+					bson.D{{Key: "$ifNull", Value: bson.A{"$prepTime", 0}}},
+					bson.D{{Key: "$ifNull", Value: bson.A{"$cookTime", 0}}},
+				}}},
+			}}}},
+		}}},
+		bson.D{{Key: "$match", Value: match}},
+		bson.D{{Key: "$sort", Value: order}},
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "total", Value: bson.A{bson.D{{Key: "$count", Value: "n"}}}},
+			{Key: "recipes", Value: page},
+		}}},
+	}
+}
+
+// facetResult is the document produced by Query.pipeline.
+type facetResult struct {
+	Total []struct {
+		N int `bson:"n"`
+	} `bson:"total"`
+	Recipes []*models.Recipe `bson:"recipes"`
+}
+
+func (f *facetResult) result() *SearchResult {
+	res := &SearchResult{Recipes: f.Recipes}
+	if len(f.Total) > 0 {
+		res.Total = f.Total[0].N
+	}
+	if res.Recipes == nil {
+		res.Recipes = []*models.Recipe{}
+	}
+	return res
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/google/go-cmp/cmp"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// searchIDs returns the IDs of the recipes found by the query.
+func searchIDs(s Searcher, q Query) ([]string, error) {
+	res, err := s.Search(context.Background(), q)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, rcp := range res.Recipes {
+		ids = append(ids, rcp.ID)
+	}
+	return ids, nil
+}
+
+func searchRecipes() []*models.Recipe {
+	potatoes := testRecipe("1", "slow-cooker-mashed-potatoes")
+	potatoes.Name = "Slow Cooker Mashed Potatoes"
+	potatoes.TotalTime = models.Duration(4 * time.Hour)
+	potatoes.Servings = 8
+	potatoes.Cost = &models.RecipeCost{Recipe: &models.Money{Cents: 460, Currency: "USD"}}
+
+	chili := testRecipe("2", "vegetarian-chili")
+	chili.Name = "Vegetarian Chili"
+	chili.Ingredients[0].Ingredients = []models.Ingredient{{Name: "black beans"}, {Name: "garlic, minced"}}
+	chili.Instructions = []string{"Simmer for 30 minutes."}
+	chili.PrepTime = models.Duration(15 * time.Minute)
+	chili.CookTime = models.Duration(30 * time.Minute)
+	chili.Cost = &models.RecipeCost{Serving: &models.Money{Cents: 125, Currency: "USD"}}
+
+	toast := testRecipe("3", "garlic-toast")
+	toast.Name = "Garlic Toast"
+	toast.Ingredients[0].Ingredients = []models.Ingredient{{Name: "bread"}, {Name: "garlic"}, {Name: "butter"}}
+	toast.Instructions = []string{"Toast the bread."}
+	toast.TotalTime = models.Duration(10 * time.Minute)
+
+	return []*models.Recipe{potatoes, chili, toast}
+}
+
+// testSearch runs the same searches against any store. The store should start out empty.
+func testSearch(t *testing.T, s interface {
+	RecipeStore
+	Searcher
+}) {
+	for _, rcp := range searchRecipes() {
+		if err := s.Insert(context.Background(), rcp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"everything", Query{}, []string{"3", "1", "2"}},
+		{"name", Query{Text: "mashed"}, []string{"1"}},
+		{"any case", Query{Text: "GARLIC"}, []string{"3", "2"}},
+		{"include", Query{Include: []string{"garlic"}}, []string{"3", "2"}},
+		{"include all", Query{Include: []string{"garlic", "beans"}}, []string{"2"}},
+		{"exclude", Query{Exclude: []string{"butter"}}, []string{"1", "2"}},
+		{"cost", Query{MaxCostPerServing: 100}, []string{"1"}}, // 460 / 8 servings
+		{"time", Query{MaxTime: models.Duration(time.Hour)}, []string{"3", "2"}},
+		{"sort by time", Query{Sort: SortTime}, []string{"3", "2", "1"}},
+		{"sort by cost", Query{Sort: SortCost, Descending: true}, []string{"2", "1", "3"}},
+		{"page", Query{Offset: 1, Limit: 1}, []string{"1"}},
+		{"past the end", Query{Offset: 5}, nil},
+	}
+	for _, test := range tests {
+		got, err := searchIDs(s, test.q)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !cmp.Equal(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	res, err := s.Search(context.Background(), Query{Include: []string{"garlic"}, Limit: 1})
+	if err != nil || res.Total != 2 || len(res.Recipes) != 1 {
+		t.Errorf("Search with a limit = %+v, %v, want 1 of 2 recipes", res, err)
+	}
+}
+
+func TestDirSearch(t *testing.T) {
+	s, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSearch(t, s)
+}
+
+func TestSQLiteSearchFilters(t *testing.T) {
+	testSearch(t, openSQLite(t))
+}
+
+// The aggregation pipeline can only really be tested against MongoDB, but the Data API should at
+// least send it and read the result.
+func TestDataAPISearch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/action/aggregate" {
+			t.Errorf("unexpected action %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Pipeline []bson.M `bson:"pipeline"`
+		}
+		if err := bson.UnmarshalExtJSON(body, false, &req); err != nil || len(req.Pipeline) != 4 {
+			t.Errorf("bad pipeline: %s", body)
+		}
+		data, _ := bson.MarshalExtJSON(bson.M{"documents": bson.A{bson.M{
+			"total":   bson.A{bson.M{"n": 2}},
+			"recipes": bson.A{searchRecipes()[1]},
+		}}}, false, false)
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	s := &DataAPI{BaseURL: srv.URL + "/action/", Collection: "recipes"}
+	res, err := s.Search(context.Background(), Query{Text: "chili", Sort: SortCost, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 || len(res.Recipes) != 1 || res.Recipes[0].Name != "Vegetarian Chili" {
+		t.Errorf("Search = %+v", res)
+	}
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/ejacobg/recipe-parser/models"
+)
+
+// encodeSnapshot compresses a snapshot for Dir: a gzip stream holding a line of JSON with the
+// URL, fetch time, status, headers and hash, followed by the page itself. `zcat` shows both.
+func encodeSnapshot(snap *models.Snapshot) ([]byte, error) {
+	meta, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(meta)
+	zw.Write([]byte("\n"))
+	zw.Write(snap.Body)
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeSnapshot reads a snapshot written by encodeSnapshot, checking the page against its hash.
+func decodeSnapshot(data []byte) (*models.Snapshot, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(zr)
+	meta, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	snap := &models.Snapshot{}
+	if err = json.Unmarshal(meta, snap); err != nil {
+		return nil, err
+	}
+	if snap.Body, err = io.ReadAll(r); err != nil {
+		return nil, err
+	}
+	return snap, snap.Verify()
+}
+
+// compress and decompress store the page on its own, for SQLite.
+func compress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(body)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
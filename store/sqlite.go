@@ -0,0 +1,648 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	_ "modernc.org/sqlite" // pure Go, so no cgo is needed
+)
+
+// SQLite stores recipes in a SQLite database file, for offline use and local development.
+// Recipes, their ingredients and their instructions are kept in separate tables so they can be
+// queried directly, and a full-text index over recipe names, ingredient names and instructions
+// backs Search.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (or creates) the database at path and brings its schema up to date.
+// Use ":memory:" for a database that only lasts until it is closed.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only allows one writer at a time anyway, and every connection to ":memory:" would
+	// otherwise get its own empty database.
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err = migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLite{db}, nil
+}
+
+// Close closes the database.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+const recipeColumns = `pk, id, url, name, image, summary, author, prep_time, cook_time, total_time,
+	servings, servings_unit, course, cuisine, keywords, nutrition, cost`
+
+// get reads the first recipe matching the WHERE clause, along with its ingredients and instructions.
+func (s *SQLite) get(ctx context.Context, where string, args ...interface{}) (*models.Recipe, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+recipeColumns+" FROM recipes WHERE "+where, args...)
+	pk, rcp, err := scanRecipe(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rcp, s.readChildren(ctx, pk, rcp)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecipe(row scanner) (int64, *models.Recipe, error) {
+	var (
+		pk                                      int64
+		rcp                                     = &models.Recipe{}
+		prep, cook, total                       int64
+		course, cuisine, keywords, nutrition, c sql.NullString
+	)
+	err := row.Scan(
+		&pk, &rcp.ID, &rcp.URL, &rcp.Name, &rcp.Image, &rcp.Summary, &rcp.Author, &prep, &cook, &total,
+		&rcp.Servings, &rcp.ServingsUnit, &course, &cuisine, &keywords, &nutrition, &c,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	rcp.PrepTime = minutes(prep)
+	rcp.CookTime = minutes(cook)
+	rcp.TotalTime = minutes(total)
+	for _, col := range []struct {
+		value sql.NullString
+		dst   interface{}
+	}{
+		{course, &rcp.Course},
+		{cuisine, &rcp.Cuisine},
+		{keywords, &rcp.Keywords},
+		{nutrition, &rcp.Nutrition},
+		{c, &rcp.Cost},
+	} {
+		if col.value.Valid {
+			if err = json.Unmarshal([]byte(col.value.String), col.dst); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	return pk, rcp, nil
+}
+
+func minutes(m int64) models.Duration {
+	return models.Duration(time.Duration(m) * time.Minute)
+}
+
+func (s *SQLite) readChildren(ctx context.Context, pk int64, rcp *models.Recipe) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT group_position, group_name, amount, unit, name, notes,
+		cost_cents, cost_currency, quantity_value, quantity_max, to_taste
+		FROM ingredients WHERE recipe = ? ORDER BY group_position, position`, pk)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			group      int
+			groupName  string
+			i          models.Ingredient
+			cents      sql.NullInt64
+			currency   sql.NullString
+			value, max sql.NullFloat64
+			toTaste    bool
+		)
+		err = rows.Scan(&group, &groupName, &i.Amount, &i.Unit, &i.Name, &i.Notes, &cents, &currency,
+			&value, &max, &toTaste)
+		if err != nil {
+			return err
+		}
+		if cents.Valid {
+			i.Cost = &models.Money{Cents: cents.Int64, Currency: currency.String}
+		}
+		if value.Valid {
+			i.Quantity = &models.Quantity{Value: value.Float64, Max: max.Float64, ToTaste: toTaste}
+		}
+		for len(rcp.Ingredients) <= group {
+			rcp.Ingredients = append(rcp.Ingredients, models.IngredientGroup{})
+		}
+		rcp.Ingredients[group].Name = groupName
+		rcp.Ingredients[group].Ingredients = append(rcp.Ingredients[group].Ingredients, i)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = s.db.QueryContext(ctx, "SELECT text FROM instructions WHERE recipe = ? ORDER BY position", pk)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var text string
+		if err = rows.Scan(&text); err != nil {
+			return err
+		}
+		rcp.Instructions = append(rcp.Instructions, text)
+	}
+	return rows.Err()
+}
+
+func (s *SQLite) GetByURL(ctx context.Context, url string) (*models.Recipe, error) {
+	return s.get(ctx, "url = ? AND url != ''", url)
+}
+
+func (s *SQLite) GetByID(ctx context.Context, id string) (*models.Recipe, error) {
+	return s.get(ctx, "id = ?", id)
+}
+
+// GetByName returns the recipe with the given name, ignoring case.
+func (s *SQLite) GetByName(ctx context.Context, name string) (*models.Recipe, error) {
+	return s.get(ctx, "name = ? COLLATE NOCASE", strings.TrimSpace(name))
+}
+
+func (s *SQLite) Insert(ctx context.Context, rcp *models.Recipe) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var n int
+		err := tx.QueryRowContext(ctx, "SELECT count(*) FROM recipes WHERE id = ? OR (url = ? AND url != '')",
+			rcp.ID, rcp.URL).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrExists
+		}
+		args, err := recipeArgs(rcp)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `INSERT INTO recipes (id, url, name, image, summary, author,
+			prep_time, cook_time, total_time, servings, servings_unit, course, cuisine, keywords, nutrition,
+			cost) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+		if err != nil {
+			return err
+		}
+		pk, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		return writeChildren(ctx, tx, pk, rcp)
+	})
+}
+
+func (s *SQLite) Replace(ctx context.Context, rcp *models.Recipe) error {
+	return s.ReplaceID(ctx, rcp.ID, rcp)
+}
+
+// ReplaceID updates the old recipe's row, so its snapshot stays with it.
+func (s *SQLite) ReplaceID(ctx context.Context, oldID string, rcp *models.Recipe) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var pk int64
+		err := tx.QueryRowContext(ctx, "SELECT pk FROM recipes WHERE id = ?", oldID).Scan(&pk)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var n int
+		err = tx.QueryRowContext(ctx, "SELECT count(*) FROM recipes WHERE pk != ? AND (id = ? OR (url = ? AND url != ''))",
+			pk, rcp.ID, rcp.URL).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrExists
+		}
+		args, err := recipeArgs(rcp)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE recipes SET id = ?, url = ?, name = ?, image = ?, summary = ?,
+			author = ?, prep_time = ?, cook_time = ?, total_time = ?, servings = ?, servings_unit = ?,
+			course = ?, cuisine = ?, keywords = ?, nutrition = ?, cost = ? WHERE pk = ?`, append(args, pk)...)
+		if err != nil {
+			return err
+		}
+		if err = deleteChildren(ctx, tx, pk); err != nil {
+			return err
+		}
+		return writeChildren(ctx, tx, pk, rcp)
+	})
+}
+
+func (s *SQLite) Delete(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var pk int64
+		err := tx.QueryRowContext(ctx, "SELECT pk FROM recipes WHERE id = ?", id).Scan(&pk)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err = deleteChildren(ctx, tx, pk); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM recipes WHERE pk = ?", pk)
+		return err
+	})
+}
+
+// List returns every recipe, sorted by name.
+func (s *SQLite) List(ctx context.Context) ([]*models.Recipe, error) {
+	return s.query(ctx, "SELECT "+recipeColumns+" FROM recipes ORDER BY name")
+}
+
+// The same calculations as Recipe.CostPerServing and Recipe.TimeNeeded, for use in queries.
+const (
+	costPerServingSQL = `COALESCE(json_extract(r.cost, '$.serving.cents'), CASE WHEN r.servings > 0 THEN
+		round(COALESCE(json_extract(r.cost, '$.recipe.cents'), json_extract(r.cost, '$.ingredients.cents')) / r.servings)
+		END)`
+	timeNeededSQL = `CASE WHEN r.total_time > 0 THEN r.total_time ELSE r.prep_time + r.cook_time END`
+)
+
+// Search uses the full-text index for the query's text, so unlike the other stores, words match
+// the start of a word (eg. "potato" finds "potatoes", but "tato" doesn't).
+func (s *SQLite) Search(ctx context.Context, q Query) (*SearchResult, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if terms := ftsTerms(q.Text); terms != "" {
+		where = append(where, "r.pk IN (SELECT rowid FROM recipes_fts WHERE recipes_fts MATCH ?)")
+		args = append(args, terms)
+	}
+	const uses = "EXISTS (SELECT 1 FROM ingredients i WHERE i.recipe = r.pk AND i.name LIKE ? ESCAPE '\\')"
+	for _, ingredient := range q.Include {
+		where = append(where, uses)
+		args = append(args, likeContains(ingredient))
+	}
+	for _, ingredient := range q.Exclude {
+		where = append(where, "NOT "+uses)
+		args = append(args, likeContains(ingredient))
+	}
+	if q.MaxCostPerServing > 0 {
+		where = append(where, costPerServingSQL+" <= ?")
+		args = append(args, q.MaxCostPerServing)
+	}
+	if q.MaxTime > 0 {
+		where = append(where, timeNeededSQL+" > 0", timeNeededSQL+" <= ?")
+		args = append(args, q.MaxTime.Minutes())
+	}
+	from := " FROM recipes r"
+	if len(where) > 0 {
+		from += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	res := &SearchResult{}
+	if err := s.db.QueryRowContext(ctx, "SELECT count(*)"+from, args...).Scan(&res.Total); err != nil {
+		return nil, err
+	}
+
+	order := "r.name"
+	switch q.Sort {
+	case SortTime:
+		order = timeNeededSQL
+	case SortCost:
+		order = costPerServingSQL
+	}
+	if q.Descending {
+		order += " DESC"
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	recipes, err := s.query(ctx, "SELECT "+prefixColumns("r.")+from+" ORDER BY "+order+", r.name LIMIT ? OFFSET ?",
+		append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	res.Recipes = recipes
+	if res.Recipes == nil {
+		res.Recipes = []*models.Recipe{}
+	}
+	return res, nil
+}
+
+// ftsTerms turns free text into a full-text query that matches every word as a prefix.
+func ftsTerms(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		// quote every word so that FTS syntax (eg. "AND", "-", "*") is matched literally
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// likeContains returns a LIKE pattern matching any text that contains s.
+func likeContains(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+func prefixColumns(prefix string) string {
+	columns := strings.Split(recipeColumns, ",")
+	for i, c := range columns {
+		columns[i] = prefix + strings.TrimSpace(c)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// query reads every recipe returned by the query, which must select recipeColumns.
+func (s *SQLite) query(ctx context.Context, query string, args ...interface{}) ([]*models.Recipe, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		pks     []int64
+		recipes []*models.Recipe
+	)
+	for rows.Next() {
+		pk, rcp, err := scanRecipe(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pks = append(pks, pk)
+		recipes = append(recipes, rcp)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// Only one connection is open, so the children can't be read while the rows above are.
+	for i, rcp := range recipes {
+		if err = s.readChildren(ctx, pks[i], rcp); err != nil {
+			return nil, err
+		}
+	}
+	return recipes, nil
+}
+
+func (s *SQLite) inTx(ctx context.Context, f func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// recipeArgs returns the values of every column in recipeColumns except pk.
+func recipeArgs(rcp *models.Recipe) ([]interface{}, error) {
+	args := []interface{}{
+		rcp.ID, rcp.URL, rcp.Name, rcp.Image, rcp.Summary, rcp.Author,
+		rcp.PrepTime.Minutes(), rcp.CookTime.Minutes(), rcp.TotalTime.Minutes(),
+		rcp.Servings, rcp.ServingsUnit,
+	}
+	for _, v := range []interface{}{rcp.Course, rcp.Cuisine, rcp.Keywords, rcp.Nutrition, rcp.Cost} {
+		s, err := jsonColumn(v)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, s)
+	}
+	return args, nil
+}
+
+// jsonColumn encodes the value as JSON, or NULL if it is empty.
+func jsonColumn(v interface{}) (sql.NullString, error) {
+	switch v := v.(type) {
+	case []string:
+		if len(v) == 0 {
+			return sql.NullString{}, nil
+		}
+	case *models.Nutrition:
+		if v == nil {
+			return sql.NullString{}, nil
+		}
+	case *models.RecipeCost:
+		if v == nil {
+			return sql.NullString{}, nil
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func deleteChildren(ctx context.Context, tx *sql.Tx, pk int64) error {
+	for _, query := range []string{
+		"DELETE FROM ingredients WHERE recipe = ?",
+		"DELETE FROM instructions WHERE recipe = ?",
+		"DELETE FROM recipes_fts WHERE rowid = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, query, pk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeChildren inserts the recipe's ingredients and instructions, and indexes the recipe.
+func writeChildren(ctx context.Context, tx *sql.Tx, pk int64, rcp *models.Recipe) error {
+	var names []string
+	for g, group := range rcp.Ingredients {
+		for p, i := range group.Ingredients {
+			var (
+				cents      sql.NullInt64
+				currency   sql.NullString
+				value, max sql.NullFloat64
+				toTaste    bool
+			)
+			if i.Cost != nil {
+				cents = sql.NullInt64{Int64: i.Cost.Cents, Valid: true}
+				currency = sql.NullString{String: i.Cost.Currency, Valid: true}
+			}
+			if i.Quantity != nil {
+				value = sql.NullFloat64{Float64: i.Quantity.Value, Valid: true}
+				max = sql.NullFloat64{Float64: i.Quantity.Max, Valid: true}
+				toTaste = i.Quantity.ToTaste
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO ingredients (recipe, group_position, group_name,
+				position, amount, unit, name, notes, cost_cents, cost_currency, quantity_value, quantity_max,
+				to_taste) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				pk, g, group.Name, p, i.Amount, i.Unit, i.Name, i.Notes, cents, currency, value, max, toTaste)
+			if err != nil {
+				return err
+			}
+			names = append(names, i.Name)
+		}
+	}
+	for p, text := range rcp.Instructions {
+		_, err := tx.ExecContext(ctx, "INSERT INTO instructions (recipe, position, text) VALUES (?, ?, ?)",
+			pk, p, text)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO recipes_fts (rowid, name, ingredients, instructions) VALUES (?, ?, ?, ?)",
+		pk, rcp.Name, strings.Join(names, " "), strings.Join(rcp.Instructions, " "))
+	return err
+}
+
+func (s *SQLite) GetPlan(ctx context.Context, id string) (*models.Plan, error) {
+	plans, err := s.plans(ctx, "WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, ErrPlanNotFound
+	}
+	return plans[0], nil
+}
+
+// plans reads the plans matching the WHERE clause, along with their meals.
+func (s *SQLite) plans(ctx context.Context, where string, args ...interface{}) ([]*models.Plan, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT p.id, p.name, m.date, m.slot, m.recipe_id, m.servings
+		FROM plans p LEFT JOIN plan_meals m ON m.plan = p.pk `+where+` ORDER BY p.id, m.date`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	plans := []*models.Plan{}
+	for rows.Next() {
+		var (
+			id, name             string
+			date, slot, recipeID sql.NullString
+			servings             sql.NullFloat64
+		)
+		if err = rows.Scan(&id, &name, &date, &slot, &recipeID, &servings); err != nil {
+			return nil, err
+		}
+		if len(plans) == 0 || plans[len(plans)-1].ID != id {
+			plans = append(plans, &models.Plan{ID: id, Name: name, Meals: []models.Meal{}})
+		}
+		if date.Valid {
+			plan := plans[len(plans)-1]
+			plan.Meals = append(plan.Meals, models.Meal{
+				Date:     date.String,
+				Slot:     models.Slot(slot.String),
+				RecipeID: recipeID.String,
+				Servings: servings.Float64,
+			})
+		}
+	}
+	for _, plan := range plans {
+		plan.Sort()
+	}
+	return plans, rows.Err()
+}
+
+func (s *SQLite) SavePlan(ctx context.Context, plan *models.Plan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var pk int64
+		err := tx.QueryRowContext(ctx, `INSERT INTO plans (id, name) VALUES (?, ?)
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name RETURNING pk`, plan.ID, plan.Name).Scan(&pk)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "DELETE FROM plan_meals WHERE plan = ?", pk); err != nil {
+			return err
+		}
+		for _, m := range plan.Meals {
+			_, err = tx.ExecContext(ctx, `INSERT INTO plan_meals (plan, date, slot, recipe_id, servings)
+				VALUES (?, ?, ?, ?, ?)`, pk, m.Date, string(m.Slot), m.RecipeID, m.Servings)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLite) DeletePlan(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM plans WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPlanNotFound
+	}
+	return nil
+}
+
+func (s *SQLite) ListPlans(ctx context.Context) ([]*models.Plan, error) {
+	return s.plans(ctx, "")
+}
+
+func (s *SQLite) SaveSnapshot(ctx context.Context, rcp *models.Recipe, snap *models.Snapshot) error {
+	header, err := json.Marshal(snap.Header)
+	if err != nil {
+		return err
+	}
+	body, err := compress(snap.Body)
+	if err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var pk int64
+		err := tx.QueryRowContext(ctx, "SELECT pk FROM recipes WHERE id = ?", rcp.ID).Scan(&pk)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO snapshots (recipe, url, fetched_at, status, header, sha256, body)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`, pk, snap.URL, snap.FetchedAt.UTC().Format(time.RFC3339Nano), snap.Status,
+			string(header), snap.SHA256, body)
+		return err
+	})
+}
+
+func (s *SQLite) GetSnapshot(ctx context.Context, rcp *models.Recipe) (*models.Snapshot, error) {
+	var (
+		snap      models.Snapshot
+		fetchedAt string
+		header    sql.NullString
+		body      []byte
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT s.url, s.fetched_at, s.status, s.header, s.sha256, s.body
+		FROM snapshots s JOIN recipes r ON r.pk = s.recipe WHERE r.id = ?`, rcp.ID).
+		Scan(&snap.URL, &fetchedAt, &snap.Status, &header, &snap.SHA256, &body)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snap.FetchedAt, err = time.Parse(time.RFC3339Nano, fetchedAt); err != nil {
+		return nil, err
+	}
+	if header.Valid {
+		if err = json.Unmarshal([]byte(header.String), &snap.Header); err != nil {
+			return nil, err
+		}
+	}
+	if snap.Body, err = decompress(body); err != nil {
+		return nil, err
+	}
+	return &snap, snap.Verify()
+}
@@ -0,0 +1,135 @@
+// Package store contains the databases that recipes can be saved to. Every database implements
+// RecipeStore, so the API routes and the CLI can work with any of them.
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+
+	"github.com/ejacobg/recipe-parser/models"
+)
+
+var (
+	// ErrNotFound is returned when no stored recipe matches the lookup.
+	ErrNotFound = errors.New("recipe not found")
+	// ErrExists is returned when inserting a recipe whose ID or URL is already stored.
+	ErrExists = errors.New("recipe already exists")
+	// ErrPlanNotFound is returned when no stored meal plan has the given ID.
+	ErrPlanNotFound = errors.New("meal plan not found")
+	// ErrNoSnapshot is returned when the page of a stored recipe wasn't kept.
+	ErrNoSnapshot = errors.New("no snapshot of the recipe's page")
+)
+
+// RecipeStore is a database of recipes. Recipes are identified by their ID, and can also be
+// looked up using their (canonicalized) URL.
+type RecipeStore interface {
+	// GetByURL returns the recipe with the given URL, or ErrNotFound.
+	GetByURL(ctx context.Context, url string) (*models.Recipe, error)
+	// GetByID returns the recipe with the given ID, or ErrNotFound.
+	GetByID(ctx context.Context, id string) (*models.Recipe, error)
+	// Insert adds the recipe, failing with ErrExists if a recipe with the same ID or URL is already
+	// stored.
+	Insert(ctx context.Context, rcp *models.Recipe) error
+	// Replace overwrites the stored recipe with the same ID, failing with ErrNotFound if there isn't
+	// one. It will not create a new recipe, use Insert.
+	Replace(ctx context.Context, rcp *models.Recipe) error
+	// Delete removes the recipe with the given ID, or returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+	// List returns every stored recipe.
+	List(ctx context.Context) ([]*models.Recipe, error)
+}
+
+// NameGetter is implemented by stores that can look recipes up by name, eg. Dir and SQLite.
+type NameGetter interface {
+	GetByName(ctx context.Context, name string) (*models.Recipe, error)
+}
+
+// PlanStore is implemented by stores that can also save meal plans, which every store in this
+// package does. Plans are kept next to the recipes, eg. in a "plans" collection or table.
+type PlanStore interface {
+	// GetPlan returns the plan with the given ID, or ErrPlanNotFound.
+	GetPlan(ctx context.Context, id string) (*models.Plan, error)
+	// SavePlan creates the plan, or overwrites the stored plan with the same ID.
+	SavePlan(ctx context.Context, plan *models.Plan) error
+	// DeletePlan removes the plan with the given ID, or returns ErrPlanNotFound.
+	DeletePlan(ctx context.Context, id string) error
+	// ListPlans returns every stored plan, sorted by ID.
+	ListPlans(ctx context.Context) ([]*models.Plan, error)
+}
+
+// SnapshotStore is implemented by the local stores (Dir and SQLite), which keep the page that each
+// recipe was parsed from next to the recipe (see models.Snapshot). Snapshots are compressed.
+type SnapshotStore interface {
+	// SaveSnapshot saves the page that the stored recipe was parsed from, replacing any earlier
+	// snapshot. It fails with ErrNotFound if the recipe isn't stored.
+	SaveSnapshot(ctx context.Context, rcp *models.Recipe, snap *models.Snapshot) error
+	// GetSnapshot returns the snapshot of the stored recipe's page, or ErrNoSnapshot.
+	GetSnapshot(ctx context.Context, rcp *models.Recipe) (*models.Snapshot, error)
+}
+
+// IDReplacer is implemented by the local stores (Dir and SQLite), which can put a recipe with a
+// new ID in the place of a stored one in one step, eg. when the parser now gives it another ID.
+type IDReplacer interface {
+	// ReplaceID replaces the recipe stored as oldID with rcp, keeping its snapshot. It fails with
+	// ErrNotFound if there is no such recipe, and ErrExists if another recipe has rcp's ID or URL.
+	ReplaceID(ctx context.Context, oldID string, rcp *models.Recipe) error
+}
+
+// SaveAs is Save for a recipe that is stored as oldID, which may differ from the recipe's own ID.
+// The stored recipe is left as it was if it can't be replaced.
+func SaveAs(ctx context.Context, s RecipeStore, oldID string, rcp *models.Recipe, snap *models.Snapshot) error {
+	if rcp.ID == oldID {
+		return Save(ctx, s, rcp, snap)
+	}
+	replacer, ok := s.(IDReplacer)
+	if !ok {
+		return errors.New("this store can't change the ID of a recipe")
+	}
+	if err := replacer.ReplaceID(ctx, oldID, rcp); err != nil {
+		return err
+	}
+	if snapshots, ok := s.(SnapshotStore); ok && snap != nil {
+		return snapshots.SaveSnapshot(ctx, rcp, snap)
+	}
+	return nil
+}
+
+// Save inserts the recipe, or replaces the stored copy if it was saved before. The snapshot of the
+// page it was parsed from is saved too, if given and the store keeps snapshots.
+func Save(ctx context.Context, s RecipeStore, rcp *models.Recipe, snap *models.Snapshot) error {
+	err := s.Insert(ctx, rcp)
+	if err == ErrExists {
+		err = s.Replace(ctx, rcp)
+	}
+	if err != nil {
+		return err
+	}
+	if snapshots, ok := s.(SnapshotStore); ok && snap != nil {
+		return snapshots.SaveSnapshot(ctx, rcp, snap)
+	}
+	return nil
+}
+
+// PlansCollection is the name of the MongoDB collection that plans are saved in, next to the recipes.
+const PlansCollection = "plans"
+
+// Open opens a local store: a SQLite database if the path ends in ".db", ".sqlite" or ".sqlite3",
+// and a directory of JSON files otherwise. Call Close when done with it.
+func Open(path string) (RecipeStore, error) {
+	switch filepath.Ext(path) {
+	case ".db", ".sqlite", ".sqlite3":
+		return OpenSQLite(path)
+	default:
+		return NewDir(path)
+	}
+}
+
+// Close closes the store if it needs closing, eg. a SQLite database.
+func Close(s RecipeStore) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
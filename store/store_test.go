@@ -0,0 +1,589 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejacobg/recipe-parser/models"
+	"github.com/google/go-cmp/cmp"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func testRecipe(id, name string) *models.Recipe {
+	return &models.Recipe{
+		ID:   id,
+		Name: name,
+		URL:  "https://www.budgetbytes.com/" + name + "/",
+		Ingredients: models.IngredientGroups{{Ingredients: []models.Ingredient{
+			{Amount: "3", Unit: "lbs.", Name: "russet potatoes"},
+		}}},
+		Instructions: []string{"Boil the potatoes."},
+	}
+}
+
+// testStore runs the same checks against any store. The store should start out empty.
+func testStore(t *testing.T, s RecipeStore) {
+	ctx := context.Background()
+	rcp := testRecipe("30990", "slow-cooker-mashed-potatoes")
+
+	if _, err := s.GetByID(ctx, rcp.ID); err != ErrNotFound {
+		t.Fatalf("GetByID on an empty store = %v, want ErrNotFound", err)
+	}
+	if err := s.Insert(ctx, rcp); err != nil {
+		t.Fatal("Insert:", err)
+	}
+	if err := s.Insert(ctx, rcp); err != ErrExists {
+		t.Errorf("second Insert = %v, want ErrExists", err)
+	}
+
+	got, err := s.GetByURL(ctx, rcp.URL)
+	if err != nil || got.Name != rcp.Name {
+		t.Errorf("GetByURL = %v, %v", got, err)
+	}
+	got, err = s.GetByID(ctx, rcp.ID)
+	if err != nil || got.URL != rcp.URL {
+		t.Errorf("GetByID = %v, %v", got, err)
+	}
+
+	rcp.Instructions = append(rcp.Instructions, "Mash the potatoes.")
+	if err = s.Replace(ctx, rcp); err != nil {
+		t.Fatal("Replace:", err)
+	}
+	got, _ = s.GetByID(ctx, rcp.ID)
+	if got == nil || len(got.Instructions) != 2 {
+		t.Errorf("Replace didn't update the recipe: %v", got)
+	}
+	if err = s.Replace(ctx, testRecipe("1", "unknown")); err != ErrNotFound {
+		t.Errorf("Replace of an unknown recipe = %v, want ErrNotFound", err)
+	}
+
+	if err = s.Insert(ctx, testRecipe("31000", "olive-oil-mashed-potatoes")); err != nil {
+		t.Fatal("Insert:", err)
+	}
+	recipes, err := s.List(ctx)
+	if err != nil || len(recipes) != 2 {
+		t.Errorf("List = %d recipes, %v, want 2", len(recipes), err)
+	}
+
+	if err = s.Delete(ctx, rcp.ID); err != nil {
+		t.Fatal("Delete:", err)
+	}
+	if err = s.Delete(ctx, rcp.ID); err != ErrNotFound {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+	if _, err = s.GetByURL(ctx, rcp.URL); err != ErrNotFound {
+		t.Errorf("GetByURL after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	s, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+}
+
+// copyDatabase copies the recipes in ../database into a temporary directory.
+func copyDatabase(t *testing.T) string {
+	dir := t.TempDir()
+	files, err := filepath.Glob("../database/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatal("no recipes in ../database", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// The recipes in ../database were saved before recipes had a URL, so they are found by file name.
+func TestDirLookup(t *testing.T) {
+	s, err := NewDir(copyDatabase(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	rcp, err := s.GetByURL(ctx, "https://www.budgetbytes.com/slow-cooker-mashed-potatoes/")
+	if err != nil || rcp.ID != "30990" {
+		t.Errorf("GetByURL = %v, %v, want recipe 30990", rcp, err)
+	}
+	for _, name := range []string{"Olive Oil Mashed Potatoes", "olive oil mashed potatoes", "olive-oil-mashed-potatoes"} {
+		rcp, err = s.GetByName(ctx, name)
+		if err != nil || rcp.ID != "46821" {
+			t.Errorf("GetByName(%q) = %v, %v, want recipe 46821", name, rcp, err)
+		}
+	}
+	recipes, err := s.List(ctx)
+	if err != nil || len(recipes) != 3 || recipes[0].Name != "Garlic Herb Mashed Potatoes" {
+		t.Errorf("List = %d recipes, %v, want 3 sorted by name", len(recipes), err)
+	}
+}
+
+func TestDirIndex(t *testing.T) {
+	dir := copyDatabase(t)
+	if _, err := NewDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, IndexFile)); err != nil {
+		t.Fatal("index wasn't saved:", err)
+	}
+
+	// changes made outside the store are picked up
+	s, err := NewDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Remove(filepath.Join(dir, "olive-oil-mashed-potatoes.json")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(testRecipe("123", "added-by-hand"))
+	if err = os.WriteFile(filepath.Join(dir, "added-by-hand.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err = s.GetByID(ctx, "46821"); err != ErrNotFound {
+		t.Errorf("GetByID of a removed file = %v, want ErrNotFound", err)
+	}
+	if _, err = s.GetByName(ctx, "added-by-hand"); err != nil {
+		t.Errorf("GetByName of an added file = %v", err)
+	}
+
+	// no temporary files are left behind
+	if err = s.Insert(ctx, testRecipe("456", "inserted")); err != nil {
+		t.Fatal(err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(dir, ".tmp-*"))
+	if len(leftovers) > 0 {
+		t.Errorf("temporary files left in the directory: %v", leftovers)
+	}
+}
+
+// Recipes without a URL are named after their ID, which comes from the page and can't be trusted.
+func TestDirFileNames(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "b")
+	s, err := NewDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	rcp := testRecipe("../../pwned", "pwned")
+	rcp.URL = ""
+	if err = Save(ctx, s, rcp, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "pwned.json")); err != nil {
+		t.Errorf("recipe wasn't saved in the store: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "pwned.json")); err == nil {
+		t.Error("recipe was saved outside the store")
+	}
+	if got, err := s.GetByID(ctx, "../../pwned"); err != nil || got.Name != "pwned" {
+		t.Errorf("GetByID = %v, %v", got, err)
+	}
+
+	rcp = testRecipe("../..", "nothing")
+	rcp.URL = ""
+	if err = s.Insert(ctx, rcp); err == nil || err == ErrExists {
+		t.Errorf("Insert of a recipe without a usable name = %v, want an error", err)
+	}
+
+	// the same recipe name on two websites
+	for i, source := range []string{"https://a.com/soup/", "https://www.b.com/soup/"} {
+		rcp = testRecipe(strconv.Itoa(i), "soup")
+		rcp.URL = source
+		if err = Save(ctx, s, rcp, nil); err != nil {
+			t.Fatalf("saving %s: %v", source, err)
+		}
+		if got, err := s.GetByURL(ctx, source); err != nil || got.ID != rcp.ID {
+			t.Errorf("GetByURL(%s) = %v, %v", source, got, err)
+		}
+	}
+	if got := FileName(rcp); got != "b-com-soup.json" {
+		t.Errorf("FileName = %s, want b-com-soup.json", got)
+	}
+	if _, err = s.GetByName(ctx, "soup"); err != nil {
+		t.Errorf("GetByName by the last part of the URL = %v", err)
+	}
+}
+
+// fakeDataAPI implements just enough of the Data API's actions to run testStore.
+func fakeDataAPI(t *testing.T) *httptest.Server {
+	var docs []*models.Recipe
+	var matches func(filter bson.M, rcp *models.Recipe) bool
+	matches = func(filter bson.M, rcp *models.Recipe) bool {
+		if or, ok := filter["$or"].(bson.A); ok {
+			for _, f := range or {
+				if matches(f.(bson.M), rcp) {
+					return true
+				}
+			}
+			return false
+		}
+		for key, value := range filter {
+			if (key == "id" && rcp.ID != value) || (key == "url" && rcp.URL != value) {
+				return false
+			}
+		}
+		return true
+	}
+	find := func(filter bson.M) int {
+		for i, rcp := range docs {
+			if matches(filter, rcp) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/ejson" || r.Header.Get("Api-Key") != "key" {
+			http.Error(w, "bad headers", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Collection  string         `bson:"collection"`
+			Filter      bson.M         `bson:"filter"`
+			Update      bson.M         `bson:"update"`
+			Replacement *models.Recipe `bson:"replacement"`
+		}
+		if err := bson.UnmarshalExtJSON(body, false, &req); err != nil || req.Collection != "recipes" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		res := bson.M{}
+		i := find(req.Filter)
+		switch action := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]; action {
+		case "findOne":
+			res["document"] = nil
+			if i >= 0 {
+				res["document"] = docs[i]
+			}
+		case "find":
+			res["documents"] = docs
+		case "updateOne":
+			if i >= 0 {
+				res["matchedCount"] = 1
+			} else {
+				// round trip the document through EJSON, the same way the real API would store it
+				data, _ := bson.MarshalExtJSON(req.Update["$setOnInsert"], false, false)
+				rcp := &models.Recipe{}
+				bson.UnmarshalExtJSON(data, false, rcp)
+				docs = append(docs, rcp)
+				res["matchedCount"] = 0
+			}
+		case "replaceOne":
+			res["matchedCount"] = 0
+			if i >= 0 {
+				docs[i] = req.Replacement
+				res["matchedCount"] = 1
+			}
+		case "deleteOne":
+			res["deletedCount"] = 0
+			if i >= 0 {
+				docs = append(docs[:i], docs[i+1:]...)
+				res["deletedCount"] = 1
+			}
+		default:
+			t.Errorf("unexpected action %q", action)
+		}
+		data, err := bson.MarshalExtJSON(res, false, false)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/ejson")
+		w.Write(data)
+	}))
+}
+
+func TestDataAPI(t *testing.T) {
+	srv := fakeDataAPI(t)
+	defer srv.Close()
+	testStore(t, &DataAPI{
+		BaseURL:    srv.URL + "/action/",
+		Key:        "key",
+		DataSource: "Cluster0",
+		Database:   "test",
+		Collection: "recipes",
+	})
+}
+
+func TestDataAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid session"})
+	}))
+	defer srv.Close()
+
+	s := &DataAPI{BaseURL: srv.URL + "/", Collection: "recipes"}
+	_, err := s.GetByID(context.Background(), "30990")
+	if err == nil || err == ErrNotFound || !strings.Contains(err.Error(), "invalid session") {
+		t.Errorf("GetByID = %v, want the API's error", err)
+	}
+}
+
+func openSQLite(t *testing.T) *SQLite {
+	s, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLite(t *testing.T) {
+	testStore(t, openSQLite(t))
+}
+
+// Every field should come back out of the normalized tables exactly as it went in.
+func TestSQLiteRoundTrip(t *testing.T) {
+	s := openSQLite(t)
+	ctx := context.Background()
+	files, _ := filepath.Glob("../recipe/test-data/solutions/*.json")
+	more, _ := filepath.Glob("../database/*.json")
+	for _, file := range append(files, more...) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &models.Recipe{}
+		if err = json.Unmarshal(data, want); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Insert(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.GetByID(ctx, want.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("recipe %s changed after saving (-want +got):\n%s", want.ID, diff)
+		}
+	}
+}
+
+func TestSQLiteSearch(t *testing.T) {
+	s := openSQLite(t)
+	ctx := context.Background()
+	potatoes := testRecipe("1", "slow-cooker-mashed-potatoes")
+	potatoes.Name = "Slow Cooker Mashed Potatoes"
+	chili := testRecipe("2", "chili")
+	chili.Name = "Vegetarian Chili"
+	chili.Ingredients[0].Ingredients = []models.Ingredient{{Name: "black beans"}, {Name: "garlic"}}
+	chili.Instructions = []string{"Simmer for 30 minutes."}
+	for _, rcp := range []*models.Recipe{potatoes, chili} {
+		if err := s.Insert(ctx, rcp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"potato", []string{"1"}},       // prefix of a word in the name
+		{"russet", []string{"1"}},       // ingredient name
+		{"simmer", []string{"2"}},       // instructions
+		{"chili garlic", []string{"2"}}, // every word has to match
+		{"garlic potato", nil},
+		{`"AND" -`, nil}, // FTS syntax is matched literally
+	}
+	for _, test := range tests {
+		got, err := searchIDs(s, Query{Text: test.query})
+		if err != nil {
+			t.Errorf("Search(%q): %v", test.query, err)
+			continue
+		}
+		if !cmp.Equal(got, test.want) {
+			t.Errorf("Search(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+
+	// the index follows changes to the recipes
+	chili.Instructions = []string{"Bake for 30 minutes."}
+	if err := s.Replace(ctx, chili); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := searchIDs(s, Query{Text: "simmer"}); len(got) != 0 {
+		t.Errorf("Search found %d recipes using replaced instructions", len(got))
+	}
+	if err := s.Delete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := searchIDs(s, Query{Text: "potato"}); len(got) != 0 {
+		t.Errorf("Search found %d deleted recipes", len(got))
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recipes.db")
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Insert(context.Background(), testRecipe("1", "slow-cooker-mashed-potatoes")); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// reopening runs nothing new, and keeps the data
+	s, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var version int
+	s.db.QueryRow("PRAGMA user_version").Scan(&version)
+	if version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+	if _, err = s.GetByID(context.Background(), "1"); err != nil {
+		t.Error("recipe lost after reopening:", err)
+	}
+
+	// a database from a newer version of the program is refused
+	s.db.Exec("PRAGMA user_version = 1000")
+	s.Close()
+	if s, err = OpenSQLite(path); err == nil {
+		s.Close()
+		t.Error("OpenSQLite accepted a newer schema")
+	}
+}
+
+// testPlans runs the same checks on any plan store. The store should start out empty.
+func testPlans(t *testing.T, s PlanStore) {
+	ctx := context.Background()
+	plan := &models.Plan{ID: "week-43", Name: "Week 43"}
+	plan.Set(models.Meal{Date: "2022-10-25", Slot: models.Dinner, RecipeID: "30990", Servings: 4})
+	plan.Set(models.Meal{Date: "2022-10-24", Slot: models.Lunch, RecipeID: "46821"})
+
+	if _, err := s.GetPlan(ctx, plan.ID); err != ErrPlanNotFound {
+		t.Fatalf("GetPlan on an empty store = %v, want ErrPlanNotFound", err)
+	}
+	if err := s.SavePlan(ctx, plan); err != nil {
+		t.Fatal("SavePlan:", err)
+	}
+	got, err := s.GetPlan(ctx, plan.ID)
+	if err != nil {
+		t.Fatal("GetPlan:", err)
+	}
+	if diff := cmp.Diff(plan, got); diff != "" {
+		t.Errorf("plan changed after saving (-want +got):\n%s", diff)
+	}
+
+	plan.Remove("2022-10-24", models.Lunch)
+	plan.Name = "Week 43 (again)"
+	if err = s.SavePlan(ctx, plan); err != nil {
+		t.Fatal("SavePlan:", err)
+	}
+	if got, _ = s.GetPlan(ctx, plan.ID); got == nil || len(got.Meals) != 1 || got.Name != plan.Name {
+		t.Errorf("SavePlan didn't replace the plan: %+v", got)
+	}
+	if err = s.SavePlan(ctx, &models.Plan{ID: "../etc"}); err == nil {
+		t.Error("SavePlan accepted an invalid ID")
+	}
+
+	if err = s.SavePlan(ctx, &models.Plan{ID: "empty", Meals: []models.Meal{}}); err != nil {
+		t.Fatal("SavePlan:", err)
+	}
+	plans, err := s.ListPlans(ctx)
+	if err != nil || len(plans) != 2 || plans[0].ID != "empty" {
+		t.Errorf("ListPlans = %v, %v, want 2 plans sorted by ID", plans, err)
+	}
+
+	if err = s.DeletePlan(ctx, plan.ID); err != nil {
+		t.Fatal("DeletePlan:", err)
+	}
+	if err = s.DeletePlan(ctx, plan.ID); err != ErrPlanNotFound {
+		t.Errorf("second DeletePlan = %v, want ErrPlanNotFound", err)
+	}
+}
+
+func TestDirPlans(t *testing.T) {
+	s, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testPlans(t, s)
+}
+
+func TestSQLitePlans(t *testing.T) {
+	testPlans(t, openSQLite(t))
+}
+
+// testSnapshots checks that a recipe's snapshot survives saving, replacing the recipe, and is
+// removed along with it. The store should start out empty.
+func testSnapshots(t *testing.T, s interface {
+	RecipeStore
+	SnapshotStore
+}) {
+	ctx := context.Background()
+	rcp := testRecipe("30990", "slow-cooker-mashed-potatoes")
+	header := http.Header{"Content-Type": {"text/html; charset=UTF-8"}, "Etag": {`"abc"`}}
+	snap := models.NewSnapshot(rcp.URL, time.Date(2022, 10, 24, 12, 0, 0, 0, time.UTC), 200, header,
+		[]byte("<html><body>Slow Cooker Mashed Potatoes</body></html>"))
+
+	if err := s.SaveSnapshot(ctx, rcp, snap); err != ErrNotFound {
+		t.Errorf("SaveSnapshot of an unsaved recipe = %v, want ErrNotFound", err)
+	}
+	if err := Save(ctx, s, rcp, nil); err != nil {
+		t.Fatal("Save:", err)
+	}
+	if _, err := s.GetSnapshot(ctx, rcp); err != ErrNoSnapshot {
+		t.Errorf("GetSnapshot = %v, want ErrNoSnapshot", err)
+	}
+	// saving again replaces the recipe and adds the snapshot
+	if err := Save(ctx, s, rcp, snap); err != nil {
+		t.Fatal("Save:", err)
+	}
+	got, err := s.GetSnapshot(ctx, rcp)
+	if err != nil {
+		t.Fatal("GetSnapshot:", err)
+	}
+	if diff := cmp.Diff(snap, got); diff != "" {
+		t.Errorf("snapshot changed after saving (-want +got):\n%s", diff)
+	}
+
+	if err = s.Delete(ctx, rcp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Insert(ctx, rcp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetSnapshot(ctx, rcp); err != ErrNoSnapshot {
+		t.Errorf("GetSnapshot after deleting the recipe = %v, want ErrNoSnapshot", err)
+	}
+}
+
+func TestDirSnapshots(t *testing.T) {
+	s, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSnapshots(t, s)
+}
+
+func TestSQLiteSnapshots(t *testing.T) {
+	testSnapshots(t, openSQLite(t))
+}
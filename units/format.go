@@ -0,0 +1,87 @@
+package units
+
+import (
+	"math"
+	"strconv"
+)
+
+// Fractions that are used in recipes, in the order they are tried.
+var kitchenFractions = []struct {
+	value float64
+	text  string
+}{
+	{1.0 / 2, "1/2"},
+	{1.0 / 3, "1/3"}, {2.0 / 3, "2/3"},
+	{1.0 / 4, "1/4"}, {3.0 / 4, "3/4"},
+	{1.0 / 8, "1/8"}, {3.0 / 8, "3/8"}, {5.0 / 8, "5/8"}, {7.0 / 8, "7/8"},
+}
+
+// FormatAmount writes a number as a whole number and a kitchen fraction (eg. "1 1/2") if it is
+// within 1% of one, and as a decimal otherwise.
+func FormatAmount(v float64) string {
+	whole := math.Floor(v)
+	frac := v - whole
+	if frac < 0.01 {
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	}
+	if frac > 0.99 {
+		return strconv.FormatFloat(whole+1, 'f', -1, 64)
+	}
+	for _, f := range kitchenFractions {
+		if math.Abs(frac-f.value) < 0.01 {
+			if whole == 0 {
+				return f.text
+			}
+			return strconv.FormatFloat(whole, 'f', -1, 64) + " " + f.text
+		}
+	}
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// Format writes a value the way it would be written for the unit: kitchen fractions for US units
+// and counts (eg. "1 1/2"), and rounded decimals for metric ones (eg. "340" or "2.5").
+func Format(v float64, u Unit) string {
+	v = Round(v, u)
+	if u.System != Metric {
+		return FormatAmount(v)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Round rounds a value to something that can be measured in the kitchen. Metric values are rounded
+// to a sensible number of digits (eg. 342.7 ml is 345 ml), and everything else is rounded to the
+// nearest kitchen fraction (eg. 0.3 cups is 1/3 cup). Small values are never rounded down to zero.
+func Round(v float64, u Unit) float64 {
+	if v <= 0 {
+		return v
+	}
+	if u.System == Metric {
+		switch {
+		case v >= 100:
+			return math.Max(math.Round(v/5)*5, 100)
+		case v >= 10:
+			return math.Round(v)
+		default:
+			return math.Max(math.Round(v*10)/10, 0.1)
+		}
+	}
+
+	if v >= 10 {
+		return math.Round(v*2) / 2
+	}
+	whole := math.Floor(v)
+	frac := v - whole
+	best := 0.0
+	if frac > 0.5 {
+		best = 1
+	}
+	for _, f := range kitchenFractions {
+		if math.Abs(frac-f.value) < math.Abs(frac-best) {
+			best = f.value
+		}
+	}
+	if whole == 0 && best == 0 {
+		best = 1.0 / 8
+	}
+	return whole + best
+}
@@ -0,0 +1,221 @@
+// Package units recognizes the units used in recipes and converts between US customary and metric.
+package units
+
+import (
+	"errors"
+	"strings"
+)
+
+// Dimension is what a unit measures. Only units with the same dimension can be converted.
+type Dimension int
+
+const (
+	Count Dimension = iota // eg. cloves, cans, slices
+	Mass
+	Volume
+)
+
+func (d Dimension) String() string {
+	switch d {
+	case Mass:
+		return "mass"
+	case Volume:
+		return "volume"
+	default:
+		return "count"
+	}
+}
+
+// System is a system of measurement.
+type System int
+
+const (
+	None System = iota // counts, which are the same in every system
+	US
+	Metric
+)
+
+// ParseSystem reads a system name as used by the CLI and API, ie. "metric", "us" or "imperial".
+func ParseSystem(s string) (System, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "metric":
+		return Metric, nil
+	case "us", "imperial", "customary":
+		return US, nil
+	}
+	return None, errors.New(`unknown unit system "` + s + `", use "metric" or "us"`)
+}
+
+func (s System) String() string {
+	switch s {
+	case US:
+		return "us"
+	case Metric:
+		return "metric"
+	default:
+		return "none"
+	}
+}
+
+// The US units are all defined exactly in terms of these, so that eg. 48 tsp is exactly 1 cup.
+const (
+	fluidOunce = 29.5735295625 // ml
+	pound      = 453.59237     // g
+)
+
+// Unit is a canonical unit of measurement.
+type Unit struct {
+	// Symbol is how the unit is written, eg. "Tbsp" or "g".
+	Symbol    string
+	Dimension Dimension
+	System    System
+	// Base is the size of the unit in grams (for mass) or millilitres (for volume). Counts are 1.
+	Base float64
+}
+
+var (
+	Teaspoon    = Unit{"tsp", Volume, US, fluidOunce / 6}
+	Tablespoon  = Unit{"Tbsp", Volume, US, fluidOunce / 2}
+	FluidOunce  = Unit{"fl. oz.", Volume, US, fluidOunce}
+	Cup         = Unit{"cup", Volume, US, fluidOunce * 8}
+	Pint        = Unit{"pint", Volume, US, fluidOunce * 16}
+	Quart       = Unit{"quart", Volume, US, fluidOunce * 32}
+	Gallon      = Unit{"gallon", Volume, US, fluidOunce * 128}
+	Millilitre  = Unit{"ml", Volume, Metric, 1}
+	Litre       = Unit{"l", Volume, Metric, 1000}
+	Ounce       = Unit{"oz.", Mass, US, pound / 16}
+	Pound       = Unit{"lb.", Mass, US, pound}
+	Gram        = Unit{"g", Mass, Metric, 1}
+	Kilogram    = Unit{"kg", Mass, Metric, 1000}
+	countSymbol = Unit{"", Count, None, 1}
+)
+
+// Aliases for each unit, compared after lowercasing and removing periods. "t" and "T" are handled
+// separately since their case matters.
+var aliases = map[string]Unit{
+	"tsp": Teaspoon, "tsps": Teaspoon, "teaspoon": Teaspoon, "teaspoons": Teaspoon,
+	"tbsp": Tablespoon, "tbsps": Tablespoon, "tbs": Tablespoon, "tbl": Tablespoon,
+	"tablespoon": Tablespoon, "tablespoons": Tablespoon,
+	"fl oz": FluidOunce, "floz": FluidOunce, "fluid ounce": FluidOunce, "fluid ounces": FluidOunce,
+	"cup": Cup, "cups": Cup, "c": Cup,
+	"pint": Pint, "pints": Pint, "pt": Pint,
+	"quart": Quart, "quarts": Quart, "qt": Quart,
+	"gallon": Gallon, "gallons": Gallon, "gal": Gallon,
+	"ml": Millilitre, "milliliter": Millilitre, "milliliters": Millilitre,
+	"millilitre": Millilitre, "millilitres": Millilitre,
+	"l": Litre, "liter": Litre, "liters": Litre, "litre": Litre, "litres": Litre,
+	"oz": Ounce, "ounce": Ounce, "ounces": Ounce,
+	"lb": Pound, "lbs": Pound, "pound": Pound, "pounds": Pound,
+	"g": Gram, "gram": Gram, "grams": Gram, "gr": Gram,
+	"kg": Kilogram, "kilogram": Kilogram, "kilograms": Kilogram, "kgs": Kilogram,
+}
+
+// Count units are recognized so that they can be told apart from unknown units, but they are never
+// converted.
+var counts = []string{
+	"clove", "can", "bunch", "slice", "piece", "stalk", "sprig", "pinch", "dash", "head", "package",
+	"pkg", "bag", "jar", "box", "stick", "leaf", "large", "medium", "small", "whole", "handful",
+	"block", "sheet", "ear", "link", "fillet", "bottle",
+}
+
+// Lookup finds the canonical unit for an alias as written in a recipe, eg. "lbs.", "Tbsp",
+// "teaspoons" or "cloves". Count units are returned with their alias as the symbol.
+func Lookup(alias string) (Unit, bool) {
+	alias = strings.TrimSpace(alias)
+	switch alias {
+	case "t":
+		return Teaspoon, true
+	case "T":
+		return Tablespoon, true
+	}
+
+	key := strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(alias, ".", ""))), " ")
+	if u, ok := aliases[key]; ok {
+		return u, true
+	}
+	for _, c := range counts {
+		if key == c || key == c+"s" || key == c+"es" || c == "leaf" && key == "leaves" {
+			u := countSymbol
+			u.Symbol = alias
+			return u, true
+		}
+	}
+	return Unit{}, false
+}
+
+// Convert changes a value from one unit to another with the same dimension.
+func Convert(v float64, from, to Unit) (float64, error) {
+	if from.Dimension != to.Dimension {
+		return 0, errors.New("can't convert " + from.Dimension.String() + " to " + to.Dimension.String())
+	}
+	if from.Dimension == Count {
+		if from.Symbol != to.Symbol {
+			return 0, errors.New("can't convert between counts")
+		}
+		return v, nil
+	}
+	return v * from.Base / to.Base, nil
+}
+
+// Best converts a value (given in `from`) to the system, picking the unit there that gives the most
+// readable number, eg. 1 cup is 236.6 ml, and 1500 g is 3.3 lb. Values that are already in the
+// system are returned as they are, since the recipe's own unit is what the cook expects (5 Tbsp
+// shouldn't become 1/3 cup). Counts can't be converted, and are returned as they are too.
+func Best(v float64, from Unit, system System) (float64, Unit) {
+	if from.System == system {
+		return v, from
+	}
+	return readable(v, from, system)
+}
+
+// Rescale re-expresses a value in the most readable unit of its own system, eg. after scaling a
+// recipe 48 tsp is 1 cup, and 1500 g is 1.5 kg.
+func Rescale(v float64, from Unit) (float64, Unit) {
+	return readable(v, from, from.System)
+}
+
+// Readable reports whether a value reads naturally in its own unit, ie. whether Rescale would keep
+// it, eg. 5 tsp but not 48 tsp (1 cup) or 1/8 cup (2 Tbsp).
+func Readable(v float64, u Unit) bool {
+	_, best := Rescale(v, u)
+	return best == u
+}
+
+// readable picks the unit in the system that gives the most readable number for the value, and
+// returns the converted value.
+func readable(v float64, from Unit, system System) (float64, Unit) {
+	if from.Dimension == Count || system == None {
+		return v, from
+	}
+	base := v * from.Base
+
+	var candidates []Unit
+	switch {
+	case from.Dimension == Mass && system == Metric:
+		candidates = []Unit{Kilogram, Gram}
+	case from.Dimension == Mass:
+		candidates = []Unit{Pound, Ounce}
+	case system == Metric:
+		candidates = []Unit{Litre, Millilitre}
+	default:
+		candidates = []Unit{Gallon, Cup, Tablespoon, Teaspoon}
+	}
+
+	// Use the largest unit that gives at least the minimum amount, eg. 1/4 cup rather than 4 Tbsp.
+	for _, u := range candidates {
+		// Allow for floating point error, so that 3 tsp is still 1 Tbsp
+		if base/u.Base >= minimum(u)-1e-9 {
+			return base / u.Base, u
+		}
+	}
+	u := candidates[len(candidates)-1]
+	return base / u.Base, u
+}
+
+// minimum is the smallest amount of a unit that reads naturally in a recipe.
+func minimum(u Unit) float64 {
+	if u == Cup {
+		return 0.25
+	}
+	return 1
+}
@@ -0,0 +1,106 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		alias string
+		want  Unit
+	}{
+		{"lbs.", Pound},
+		{"lb", Pound},
+		{"Tbsp", Tablespoon},
+		{"T", Tablespoon},
+		{"tsp", Teaspoon},
+		{"t", Teaspoon},
+		{"oz.", Ounce},
+		{"fl. oz.", FluidOunce},
+		{"Cups", Cup},
+		{"ml", Millilitre},
+		{"grams", Gram},
+	}
+
+	for _, test := range tests {
+		got, ok := Lookup(test.alias)
+		if !ok || got != test.want {
+			t.Errorf("Lookup(%q) = %v, want %v", test.alias, got, test.want)
+		}
+	}
+
+	for _, alias := range []string{"cloves", "can", "large", "bunches", "leaves"} {
+		got, ok := Lookup(alias)
+		if !ok || got.Dimension != Count || got.Symbol != alias {
+			t.Errorf("Lookup(%q) = %v, want a count", alias, got)
+		}
+	}
+	if _, ok := Lookup("handfuls of love"); ok {
+		t.Error("unknown units should not be found")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	got, err := Convert(3, Pound, Gram)
+	if err != nil || math.Abs(got-1360.777) > 0.001 {
+		t.Errorf("Convert(3 lb, g) = %v, %v", got, err)
+	}
+	if _, err = Convert(1, Cup, Gram); err == nil {
+		t.Error("converting volume to mass should fail")
+	}
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		v      float64
+		from   Unit
+		system System
+		want   float64
+		unit   Unit
+	}{
+		{1, Cup, Metric, 236.588, Millilitre},
+		{5, Cup, Metric, 1.18294, Litre},
+		{3, Pound, Metric, 1.360776, Kilogram},
+		{500, Gram, US, 1.10231, Pound},
+		{100, Gram, US, 3.5274, Ounce},
+		{15, Millilitre, US, 1.01442, Tablespoon},
+		// already in the system, so left alone
+		{5, Tablespoon, US, 5, Tablespoon},
+		{48, Teaspoon, US, 48, Teaspoon},
+		{1500, Gram, Metric, 1500, Gram},
+	}
+
+	for _, test := range tests {
+		got, unit := Best(test.v, test.from, test.system)
+		if unit != test.unit || math.Abs(got-test.want) > 0.001 {
+			t.Errorf("Best(%v %s, %s) = %v %s, want %v %s", test.v, test.from.Symbol, test.system, got,
+				unit.Symbol, test.want, test.unit.Symbol)
+		}
+	}
+}
+
+func TestRescale(t *testing.T) {
+	tests := []struct {
+		v    float64
+		from Unit
+		want float64
+		unit Unit
+	}{
+		{48, Teaspoon, 1, Cup},
+		{3, Teaspoon, 1, Tablespoon},
+		{2, Tablespoon, 2, Tablespoon},
+		{4, Tablespoon, 0.25, Cup},
+		{0.5, Teaspoon, 0.5, Teaspoon},
+		{24, Ounce, 1.5, Pound},
+		{1500, Gram, 1.5, Kilogram},
+	}
+
+	for _, test := range tests {
+		got, unit := Rescale(test.v, test.from)
+		if unit != test.unit || math.Abs(got-test.want) > 0.001 {
+			t.Errorf("Rescale(%v %s) = %v %s, want %v %s", test.v, test.from.Symbol, got, unit.Symbol,
+				test.want, test.unit.Symbol)
+		}
+	}
+}